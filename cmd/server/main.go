@@ -1,20 +1,55 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	app2 "github.com/IT-Nick/internal/app"
+	"log"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run запускает приложение и останавливает его по SIGINT/SIGTERM, возвращает код завершения процесса
+func run() int {
 	fmt.Println("app starting")
 
 	app, err := app2.NewApp(os.Getenv("CONFIG_PATH"))
 	if err != nil {
-		panic(err)
+		log.Printf("failed to create app: %v", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- app.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		log.Printf("shutdown signal received")
+	case err := <-serveErr:
+		if err != nil {
+			log.Printf("app stopped with error: %v", err)
+			exitCode = 1
+		}
 	}
 
-	if err := app.ListenAndServe(); err != nil {
-		panic(err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.ShutdownTimeout())
+	defer cancel()
+
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		log.Printf("graceful shutdown failed: %v", err)
+		exitCode = 1
 	}
+
+	return exitCode
 }
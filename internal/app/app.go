@@ -1,21 +1,39 @@
 package app
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"github.com/IT-Nick/internal/app/handlers/http/active_tests_handler"
+	"github.com/IT-Nick/internal/app/handlers/http/bulk_assignment_handler"
 	"github.com/IT-Nick/internal/app/handlers/http/generate_test_link_handler"
+	"github.com/IT-Nick/internal/app/handlers/http/qr_code_handler"
+	"github.com/IT-Nick/internal/app/handlers/http/revoke_test_link_handler"
+	"github.com/IT-Nick/internal/app/handlers/http/telegram_login_handler"
+	"github.com/IT-Nick/internal/app/handlers/http/test_links_handler"
 	"github.com/IT-Nick/internal/app/handlers/http/update_user_role_handler"
 	"github.com/IT-Nick/internal/app/handlers/http/user_test_report_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/answer_handler"
+	"github.com/IT-Nick/internal/app/handlers/telegram/api_key_handler"
+	"github.com/IT-Nick/internal/app/handlers/telegram/assign_role_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/assign_tests/assign_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/assign_tests/assign_next_page_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/assign_tests/assign_prev_page_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/assign_tests/assign_test_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/assign_tests/select_test_handler"
+	"github.com/IT-Nick/internal/app/handlers/telegram/assignments_handler"
+	"github.com/IT-Nick/internal/app/handlers/telegram/qr_handler"
+	"github.com/IT-Nick/internal/app/handlers/telegram/question_sender"
+	"github.com/IT-Nick/internal/app/handlers/telegram/review_answers_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/start_handler"
 	"github.com/IT-Nick/internal/app/handlers/telegram/start_test_handler"
+	authRepo "github.com/IT-Nick/internal/domain/auth/repository"
+	authService "github.com/IT-Nick/internal/domain/auth/service"
 	msgRepo "github.com/IT-Nick/internal/domain/messages/repository"
 	msgService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
 	rolesRepo "github.com/IT-Nick/internal/domain/roles/repository"
 	rolesService "github.com/IT-Nick/internal/domain/roles/service"
 	testsRepo "github.com/IT-Nick/internal/domain/tests/repository"
@@ -23,17 +41,26 @@ import (
 	"github.com/IT-Nick/internal/domain/users/repository"
 	"github.com/IT-Nick/internal/domain/users/service"
 	"github.com/IT-Nick/internal/infra/config"
+	"github.com/IT-Nick/internal/infra/deadline"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
+	"github.com/IT-Nick/internal/infra/qr"
+	"github.com/IT-Nick/internal/infra/reminder"
 	"github.com/IT-Nick/internal/infra/timer"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"gopkg.in/telebot.v4"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type LocalStatesHelpers struct {
-	pageState       map[int64]int
-	assignTestState map[int64]int
+	pageState          map[int64]int
+	assignTestState    *model.AssignTestState
+	reviewCommentState map[int64]int
+	assignRoleState    map[int64]string
+	pickerState        *model.TestPickerState
 }
 
 type Services struct {
@@ -41,6 +68,7 @@ type Services struct {
 	messageService *msgService.MessageService
 	roleService    *rolesService.RoleService
 	testService    *testsService.TestService
+	authService    *authService.AuthService
 }
 
 type App struct {
@@ -48,7 +76,19 @@ type App struct {
 	bot          *telebot.Bot
 	db           *pgxpool.Pool
 	server       *http.Server
+	webhook      *webhookPoller // Заполняется в режиме webhook, обслуживается HTTP сервером
 	timerUpdater *timer.Updater
+	deadlines    *deadline.Watcher
+	reminders    *reminder.Scheduler
+	qrRenderer   *qr.Renderer
+	location     *time.Location // Часовой пояс дат в сообщениях (config.Timezone)
+	publicURL    string         // Внешний адрес HTTP сервера (config.Server.PublicURL)
+
+	lifecycle      sync.Mutex         // Защищает запуск серверов от гонки с Shutdown
+	stopping       bool               // Shutdown уже вызван, новые серверы не запускаются
+	activeHandlers atomic.Int64       // Количество выполняющихся обработчиков Telegram
+	cancelTimers   context.CancelFunc // Останавливает планировщик таймеров и фоновые задачи
+	timersDone     chan struct{}      // Закрывается после остановки планировщика таймеров и фоновых задач
 
 	Services
 	states LocalStatesHelpers
@@ -69,12 +109,30 @@ func NewApp(configPath string) (*App, error) {
 		config: configImpl,
 		db:     db,
 		states: LocalStatesHelpers{
-			pageState:       make(map[int64]int),
-			assignTestState: make(map[int64]int),
+			pageState:          make(map[int64]int),
+			assignTestState:    model.NewAssignTestState(),
+			reviewCommentState: make(map[int64]int),
+			assignRoleState:    make(map[int64]string),
+			pickerState:        model.NewTestPickerState(),
 		},
 	}
 
+	app.location, err = configImpl.Location()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone: %w", err)
+	}
+
+	app.publicURL, err = configImpl.PublicBaseURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load public url: %w", err)
+	}
+
 	app.initServices()
+
+	app.qrRenderer, err = app.newQRRenderer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize qr renderer: %w", err)
+	}
 	return app, nil
 }
 
@@ -85,29 +143,159 @@ func (app *App) initServices() {
 	messageRepo := msgRepo.NewMessageRepository(app.db)
 	rolePermissionRepo := rolesRepo.NewRolePermissionRepository(app.db)
 	testRepo := testsRepo.NewTestRepository(app.db)
+	authRepository := authRepo.NewAuthRepository(app.db)
 
 	// Инициализация сервисов
 	app.userService = service.NewUserService(userRepo, rolePermissionRepo)
 	app.messageService = msgService.NewMessageService(messageRepo)
 	app.roleService = rolesService.NewRoleService(rolePermissionRepo)
 	app.testService = testsService.NewTestService(testRepo, userRepo)
+	app.authService = authService.NewAuthService(authRepository, userRepo, app.sessionSettings())
+}
+
+// newPoller создает источник обновлений Telegram в соответствии с telegram_bot.mode
+func (app *App) newPoller() (telebot.Poller, error) {
+	botConfig := app.config.TelegramBot
+
+	switch botConfig.Mode {
+	case "", config.BotModePolling:
+		return &telebot.LongPoller{Timeout: 10 * time.Second}, nil
+	case config.BotModeWebhook:
+		if botConfig.WebhookURL == "" {
+			return nil, fmt.Errorf("telegram_bot.webhook_url is required in webhook mode")
+		}
+		if botConfig.WebhookSecret == "" {
+			return nil, fmt.Errorf("telegram_bot.webhook_secret is required in webhook mode")
+		}
+		// Listen не задан: вебхук монтируется на общий http.ServeMux в ListenAndServeHTTP
+		app.webhook = newWebhookPoller(&telebot.Webhook{
+			SecretToken: botConfig.WebhookSecret,
+			Endpoint:    &telebot.WebhookEndpoint{PublicURL: botConfig.WebhookURL},
+		})
+		return app.webhook, nil
+	default:
+		return nil, fmt.Errorf("unknown telegram_bot.mode %q", botConfig.Mode)
+	}
+}
+
+// newQRRenderer создает генератор QR-кодов ссылок на тесты с учетом значений по умолчанию
+func (app *App) newQRRenderer() (*qr.Renderer, error) {
+	qrConfig := app.config.QR
+	if qrConfig.Size <= 0 {
+		qrConfig.Size = config.DefaultQRSize
+	}
+	if qrConfig.Level == "" {
+		qrConfig.Level = config.DefaultQRLevel
+	}
+	return qr.NewRenderer(qrConfig.Size, qrConfig.Level, qrConfig.LogoPath)
+}
+
+// sessionSettings возвращает параметры сессий HR панели с учетом значений по умолчанию
+func (app *App) sessionSettings() authService.SessionSettings {
+	settings := authService.SessionSettings{
+		BotToken:    app.config.TelegramBot.Token,
+		Secret:      app.config.Auth.SessionSecret,
+		TTL:         app.config.Auth.SessionTTL,
+		LoginMaxAge: app.config.Auth.LoginMaxAge,
+	}
+	if settings.TTL <= 0 {
+		settings.TTL = config.DefaultSessionTTL
+	}
+	if settings.LoginMaxAge <= 0 {
+		settings.LoginMaxAge = config.DefaultLoginMaxAge
+	}
+	return settings
+}
+
+// callbackKey возвращает ключ подписи кнопок ответа на вопросы
+func (app *App) callbackKey() []byte {
+	if app.config.TelegramBot.CallbackSecret != "" {
+		return []byte(app.config.TelegramBot.CallbackSecret)
+	}
+	mac := hmac.New(sha256.New, []byte("hr-bot-callback"))
+	mac.Write([]byte(app.config.TelegramBot.Token))
+	return mac.Sum(nil)
 }
 
 // ListenAndServeTelegram запускает сервер Telegram бота
 func (app *App) ListenAndServeTelegram() error {
+	app.lifecycle.Lock()
+	defer app.lifecycle.Unlock()
+	if app.stopping {
+		return nil
+	}
+
+	poller, err := app.newPoller()
+	if err != nil {
+		return fmt.Errorf("app.newPoller: %w", err)
+	}
+
 	bot, err := telebot.NewBot(telebot.Settings{
 		Token:  app.config.TelegramBot.Token,
-		Poller: &telebot.LongPoller{Timeout: 10 * time.Second},
+		Poller: poller,
 	})
 	if err != nil {
 		return fmt.Errorf("telebot.NewBot: %w", err)
 	}
 	app.bot = bot
 
-	app.timerUpdater = timer.NewTimerUpdater(app.bot, app.testService, app.userService)
+	// Вебхук регистрируется до запуска HTTP сервера: без него бот не получит ни одного обновления
+	if app.webhook != nil {
+		if err := app.webhook.register(app.bot); err != nil {
+			return fmt.Errorf("app.webhook.register: %w", err)
+		}
+	}
+
+	app.timerUpdater = timer.NewTimerUpdater(
+		app.bot,
+		app.testService,
+		app.userService,
+		app.config.Timer.EditsPerSecond,
+		app.config.Timer.Burst,
+	)
 
+	app.deadlines = deadline.NewWatcher(
+		app.bot,
+		app.testService,
+		app.messageService,
+		app.config.Deadlines.CheckInterval,
+		app.location,
+	)
+	app.reminders = reminder.NewScheduler(
+		app.bot,
+		app.testService,
+		app.messageService,
+		app.config.Reminders.CheckInterval,
+		app.config.Reminders.BeforeDeadline,
+		app.config.Reminders.AfterAssignment,
+		app.location,
+	)
+
+	// Middleware учета обработчиков должен быть добавлен до их регистрации
+	app.bot.Use(app.trackHandlers)
 	app.bootstrapHandlersTelegram()
 
+	timersCtx, cancelTimers := context.WithCancel(context.Background())
+	app.cancelTimers = cancelTimers
+	app.timersDone = make(chan struct{})
+	go func() {
+		defer close(app.timersDone)
+
+		var wg sync.WaitGroup
+		for _, run := range []func(context.Context){
+			app.timerUpdater.Run,
+			app.deadlines.Run,
+			app.reminders.Run,
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(timersCtx)
+			}()
+		}
+		wg.Wait()
+	}()
+
 	go app.bot.Start()
 
 	return nil
@@ -121,27 +309,49 @@ func (app *App) bootstrapHandlersTelegram() {
 			app.messageService,
 			app.roleService,
 			app.testService,
+			app.location,
 		).GetHandlerFunc())
 
 	// Обработчики назначения теста кандидату (с обработчиками пагинации). OnCallback обработчик принимает айди теста.
-	app.bot.Handle(&telebot.InlineButton{Unique: "assign_test"},
-		assign_handler.NewAssignStartPageHandler(
-			app.userService,
-			app.testService,
-			app.states.pageState,
-		).GetHandlerFunc())
+	assignStartPageHandler := assign_handler.NewAssignStartPageHandler(
+		app.userService,
+		app.testService,
+		app.states.pageState,
+		app.states.pickerState,
+	)
+	app.bot.Handle(&telebot.InlineButton{Unique: "assign_test"}, func(c telebot.Context) error {
+		// Список тестов открывается для назначения, а не для генерации QR-кода
+		app.states.pickerState.Delete(c.Sender().ID)
+		return assignStartPageHandler.Handle(c)
+	})
 	app.bot.Handle(&telebot.InlineButton{Unique: "next_page"},
 		assign_next_page_handler.NewAssignNextPageHandler(
 			app.userService,
 			app.testService,
 			app.states.pageState,
+			app.states.pickerState,
 		).GetHandlerFunc())
 	app.bot.Handle(&telebot.InlineButton{Unique: "prev_page"},
 		assign_prev_page_handler.NewAssignPrevPageHandler(
 			app.userService,
 			app.testService,
 			app.states.pageState,
+			app.states.pickerState,
 		).GetHandlerFunc())
+
+	// Генерация QR-кода ссылки на тест в чате. Тест выбирается в том же списке с пагинацией
+	qrHandler := qr_handler.NewQRHandler(
+		app.userService,
+		app.testService,
+		app.messageService,
+		app.qrRenderer,
+		app.config.TelegramBot.BotUsername,
+		assignStartPageHandler.GetHandlerFunc(),
+		app.states.pickerState,
+		app.location,
+	)
+	app.bot.Handle("/qr", qrHandler.GetHandlerFunc())
+	app.bot.Handle(&telebot.InlineButton{Unique: model.GenerateQRKey}, qrHandler.GetHandlerFunc())
 	app.bot.Handle(&telebot.InlineButton{Unique: "start_page"}, func(c telebot.Context) error {
 		if c.Sender() != nil {
 			return c.Send("Вы находитесь в начале списка.")
@@ -154,6 +364,59 @@ func (app *App) bootstrapHandlersTelegram() {
 		}
 		return nil
 	})
+	// Обработчики ручной проверки свободных ответов
+	reviewAnswersHandler := review_answers_handler.NewReviewAnswersHandler(
+		app.bot,
+		app.userService,
+		app.testService,
+		app.states.reviewCommentState,
+	)
+	app.bot.Handle(&telebot.InlineButton{Unique: model.ReviewAnswersKey}, reviewAnswersHandler.GetHandlerFunc())
+	app.bot.Handle("/review", reviewAnswersHandler.GetHandlerFunc())
+
+	// Выпуск API ключа для HTTP API
+	app.bot.Handle("/apikey",
+		api_key_handler.NewAPIKeyHandler(
+			app.authService,
+			app.userService,
+			app.messageService,
+		).GetHandlerFunc())
+
+	// Назначение ролей HR и ADMIN из бота
+	assignRoleHandler := assign_role_handler.NewAssignRoleHandler(
+		app.bot,
+		app.userService,
+		app.messageService,
+		app.states.assignRoleState,
+	)
+	app.bot.Handle(&telebot.InlineButton{Unique: model.AssignHRKey}, assignRoleHandler.GetHandlerFunc(model.RoleManager))
+	app.bot.Handle(&telebot.InlineButton{Unique: model.AssignAdminKey}, assignRoleHandler.GetHandlerFunc(model.RoleAdmin))
+
+	// Управление назначениями HR: отмена, замена теста, передача другому HR и повторное приглашение
+	assignmentsHandler := assignments_handler.NewAssignmentsHandler(
+		app.bot,
+		app.userService,
+		app.testService,
+		app.messageService,
+		app.location,
+	)
+	app.bot.Handle("/assignments", assignmentsHandler.GetHandlerFunc())
+
+	// Назначение выбранного теста кандидатам (username, список или CSV файл) со сроком начала теста
+	assignTestHandler := assign_test_handler.NewAssignTestHandler(
+		app.userService,
+		app.testService,
+		app.states.assignTestState,
+		app.location,
+	)
+
+	// Кнопки ответа на вопросы подписываются, чтобы кандидат не мог подменить ответ или назначение
+	callbackSigner := question_sender.NewCallbackSigner(app.callbackKey())
+
+	// Ответы кандидата на вопросы. Обработчик также показывает планировщику таймеров вопросы с ограничением времени
+	answerHandler := answer_handler.NewAnswerHandler(app.bot, app.testService, app.userService, app.timerUpdater, callbackSigner)
+	app.timerUpdater.SetQuestionPresenter(answerHandler)
+
 	app.bot.Handle(telebot.OnCallback, func(c telebot.Context) error {
 		data := c.Callback().Data
 
@@ -161,7 +424,10 @@ func (app *App) bootstrapHandlersTelegram() {
 		cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
 		cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
 
-		// Проверяем, начинается ли callback с "test_"
+		// Проверяем, начинается ли callback с "test_"; тест может выбираться для QR-кода
+		if strings.HasPrefix(cleanedData, "test_") && qrHandler.AwaitsTest(c.Sender().ID) {
+			return qrHandler.HandleTestSelected(c)
+		}
 		if strings.HasPrefix(cleanedData, "test_") {
 			return select_test_handler.NewSelectTestHandler(
 				app.userService,
@@ -169,20 +435,78 @@ func (app *App) bootstrapHandlersTelegram() {
 				app.states.assignTestState).Handle(c)
 		}
 
-		// Проверяем callback для ответа на вопрос
-		if strings.HasPrefix(cleanedData, "answer_") {
-			return answer_handler.NewAnswerHandler(app.bot, app.testService, app.userService).Handle(c)
+		// Проверяем callback выбора срока начала назначаемого теста
+		if strings.HasPrefix(cleanedData, "deadline_") {
+			return assignTestHandler.HandleDeadline(c)
+		}
+
+		// Проверяем callback для ответа на вопрос (в том числе выбор вариантов в вопросе с несколькими ответами)
+		if strings.HasPrefix(cleanedData, "answer_") ||
+			strings.HasPrefix(cleanedData, "toggle_") ||
+			strings.HasPrefix(cleanedData, "confirm_") ||
+			strings.HasPrefix(cleanedData, "nav_") {
+			return answerHandler.Handle(c)
+		}
+
+		// Проверяем callback ручной проверки ответа
+		if strings.HasPrefix(cleanedData, "grade_") {
+			return reviewAnswersHandler.HandleCallback(c)
+		}
+
+		// Проверяем callback выбора параметров QR-кода
+		if strings.HasPrefix(cleanedData, "qr_") {
+			return qrHandler.HandleCallback(c)
+		}
+
+		// Проверяем callback подтверждения или отмены назначения роли
+		if strings.HasPrefix(cleanedData, "role_") {
+			return assignRoleHandler.HandleCallback(c)
+		}
+
+		// Проверяем callback действий со списком назначений HR
+		if strings.HasPrefix(cleanedData, "asg_") {
+			return assignmentsHandler.HandleCallback(c)
 		}
 
 		return nil
 	})
 
-	app.bot.Handle(telebot.OnText,
-		assign_test_handler.NewAssignTestHandler(
-			app.userService,
-			app.testService,
-			app.states.assignTestState,
-		).GetHandlerFunc())
+	// Текстовые сообщения: ответ кандидата на вопрос с типом "text" или username кандидата при назначении теста
+	app.bot.Handle(telebot.OnText, func(c telebot.Context) error {
+		// Менеджер вводит комментарий к оценке ответа
+		if reviewAnswersHandler.AwaitsComment(c.Sender().ID) {
+			return reviewAnswersHandler.HandleComment(c)
+		}
+
+		// Администратор вводит username пользователя, которому назначается роль
+		if assignRoleHandler.AwaitsUsername(c.Sender().ID) {
+			return assignRoleHandler.HandleUsername(c)
+		}
+
+		// HR вводит username коллеги, которому передает назначение
+		if assignmentsHandler.AwaitsUsername(c.Sender().ID) {
+			return assignmentsHandler.HandleUsername(c)
+		}
+
+		// HR в процессе назначения теста вводит username кандидата
+		if assignTestHandler.AwaitsUsernames(c.Sender().ID) {
+			return assignTestHandler.Handle(c)
+		}
+
+		handled, err := answerHandler.HandleText(c)
+		if handled || err != nil {
+			return err
+		}
+		return assignTestHandler.Handle(c)
+	})
+
+	// CSV файл со списком кандидатов для массового назначения выбранного теста
+	app.bot.Handle(telebot.OnDocument, func(c telebot.Context) error {
+		if assignTestHandler.AwaitsUsernames(c.Sender().ID) {
+			return assignTestHandler.HandleDocument(c)
+		}
+		return nil
+	})
 
 	// Обработчик запуска теста (с логикой нахождения назначенных тестов кандидату)
 	app.bot.Handle(&telebot.InlineButton{Unique: "start_test"},
@@ -192,39 +516,87 @@ func (app *App) bootstrapHandlersTelegram() {
 			app.messageService,
 			app.userService,
 			app.timerUpdater,
+			callbackSigner,
+			app.location,
 		).GetHandlerFunc())
 }
 
+// authorized оборачивает обработчик проверкой API ключа или сессии и права permission
+func (app *App) authorized(permission string, h http.Handler) http.Handler {
+	return middlewares.NewAuthMiddleware(h, app.authService, app.roleService, permission)
+}
+
 // ListenAndServeHTTP запускает HTTP сервер
 func (app *App) ListenAndServeHTTP() error {
 	mx := http.NewServeMux()
 
-	mx.Handle("POST /users/update-role", update_user_role_handler.NewUpdateUserRoleHandler(
+	// Маршруты API доступны только по API ключу или сессии пользователя с соответствующим правом
+	mx.Handle("POST /users/update-role", app.authorized(model.PermissionAssignHR, update_user_role_handler.NewUpdateUserRoleHandler(
 		app.userService,
 		app.roleService,
-	))
-	mx.Handle("POST /reports/user", user_test_report_handler.NewUserTestReportHandler(
+	)))
+	mx.Handle("POST /reports/user", app.authorized(model.PermissionViewReports, user_test_report_handler.NewUserTestReportHandler(
 		app.userService,
 		app.testService,
-	))
-	mx.Handle("GET /reports/active-tests", active_tests_handler.NewActiveTestsHandler(
+	)))
+	mx.Handle("GET /reports/active-tests", app.authorized(model.PermissionViewReports, active_tests_handler.NewActiveTestsHandler(
 		app.userService,
 		app.testService,
-	))
-	mx.Handle("POST /tests/generate-link", generate_test_link_handler.NewGenerateTestLinkHandler(
+	)))
+	mx.Handle("POST /tests/generate-link", app.authorized(model.PermissionGenerateQR, generate_test_link_handler.NewGenerateTestLinkHandler(
 		app.testService,
-		app.userService,
-		app.roleService,
 		app.config.TelegramBot.BotUsername,
-		app.config.Server.Host+":"+app.config.Server.Port,
+		app.publicURL,
+	)))
+	mx.Handle("POST /assignments/bulk", app.authorized(model.PermissionAssignTest, bulk_assignment_handler.NewBulkAssignmentHandler(
+		app.testService,
+	)))
+	mx.Handle("GET /tests/links", app.authorized(model.PermissionGenerateQR, test_links_handler.NewTestLinksHandler(
+		app.testService,
+	)))
+	mx.Handle("POST /tests/links/revoke", app.authorized(model.PermissionGenerateQR, revoke_test_link_handler.NewRevokeTestLinkHandler(
+		app.testService,
+	)))
+
+	// QR-коды ссылок на тесты для печати. Токен ссылки сам является секретом, поэтому маршрут не требует ключа
+	mx.Handle("GET /qr/{token}", qr_code_handler.NewQRCodeHandler(
+		app.testService,
+		app.qrRenderer,
+		app.config.TelegramBot.BotUsername,
 	))
 
+	// Вход в HR панель через Telegram Login Widget
+	telegramLoginHandler := telegram_login_handler.NewTelegramLoginHandler(app.authService)
+	mx.Handle("GET /auth/telegram", telegramLoginHandler)
+	mx.Handle("POST /auth/telegram", telegramLoginHandler)
+
+	// В режиме webhook обновления Telegram принимаются тем же HTTP сервером
+	if app.webhook != nil {
+		webhookPath := app.config.TelegramBot.WebhookPath
+		if webhookPath == "" {
+			webhookPath = config.DefaultWebhookPath
+		}
+		mx.Handle("POST "+webhookPath, middlewares.NewTelegramSecretMiddleware(
+			app.webhook,
+			app.config.TelegramBot.WebhookSecret,
+		))
+	}
+
+	app.lifecycle.Lock()
+	if app.stopping {
+		app.lifecycle.Unlock()
+		return nil
+	}
 	app.server = &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", app.config.Server.Host, app.config.Server.Port),
 		Handler: mx,
 	}
+	app.lifecycle.Unlock()
 
-	return app.server.ListenAndServe()
+	if err := app.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // ListenAndServe запускает оба сервера (Telegram и HTTP)
@@ -0,0 +1,89 @@
+package bulk_assignment_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
+	httpError "github.com/IT-Nick/pkg/http"
+	"net/http"
+	"time"
+)
+
+// BulkAssignmentHandler структура для обработчика массового назначения теста
+type BulkAssignmentHandler struct {
+	testService *testsService.TestService
+}
+
+// NewBulkAssignmentHandler создает новый экземпляр обработчика
+func NewBulkAssignmentHandler(testService *testsService.TestService) *BulkAssignmentHandler {
+	return &BulkAssignmentHandler{
+		testService: testService,
+	}
+}
+
+// ServeHTTP назначает тест списку пользователей от имени текущего пользователя
+func (h *BulkAssignmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req BulkAssignmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.TestID <= 0 {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Missing test_id")
+		return
+	}
+	if len(req.Usernames) == 0 {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Missing usernames")
+		return
+	}
+	if req.Deadline != nil && !req.Deadline.After(time.Now()) {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "deadline must be in the future")
+		return
+	}
+	if err := testsService.ValidateAvailabilityWindow(req.AvailabilityWindow); err != nil {
+		httpError.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	user := middlewares.UserFromContext(ctx)
+
+	// Проверяем, существует ли тест
+	test, err := h.testService.GetTestByID(ctx, req.TestID)
+	if err != nil || test == nil {
+		httpError.ErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Test with ID %d not found", req.TestID))
+		return
+	}
+
+	results, err := h.testService.BulkAssignTest(ctx, req.TestID, req.Usernames, user.TelegramUsername, req.Deadline, req.AvailabilityWindow)
+	if errors.Is(err, testsService.ErrTooManyUsernames) {
+		httpError.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to assign test: %v", err))
+		return
+	}
+
+	counts := testsService.CountBulkResults(results)
+	response := BulkAssignmentResponse{
+		TestID:    req.TestID,
+		Total:     len(results),
+		Assigned:  counts[model.BulkAssignAssigned],
+		Pending:   counts[model.BulkAssignPending],
+		Duplicate: counts[model.BulkAssignDuplicate],
+		Invalid:   counts[model.BulkAssignInvalid],
+		Results:   results,
+	}
+
+	// Отправляем успешный ответ
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
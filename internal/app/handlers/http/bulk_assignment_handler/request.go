@@ -0,0 +1,16 @@
+package bulk_assignment_handler
+
+import (
+	"github.com/IT-Nick/internal/domain/model"
+	"time"
+)
+
+// BulkAssignmentRequest структура для данных запроса
+type BulkAssignmentRequest struct {
+	TestID    int        `json:"test_id"`
+	Usernames []string   `json:"usernames"`
+	Deadline  *time.Time `json:"deadline,omitempty"` // срок, до которого кандидаты должны начать тест, по умолчанию без срока
+
+	// Окно доступности (available_from, available_until): когда тест можно начать, по умолчанию в любое время
+	model.AvailabilityWindow
+}
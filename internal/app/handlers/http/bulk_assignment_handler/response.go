@@ -0,0 +1,14 @@
+package bulk_assignment_handler
+
+import "github.com/IT-Nick/internal/domain/model"
+
+// BulkAssignmentResponse структура для ответа
+type BulkAssignmentResponse struct {
+	TestID    int                          `json:"test_id"`
+	Total     int                          `json:"total"`
+	Assigned  int                          `json:"assigned"`
+	Pending   int                          `json:"pending"`
+	Duplicate int                          `json:"duplicate"`
+	Invalid   int                          `json:"invalid"`
+	Results   []model.BulkAssignmentResult `json:"results"`
+}
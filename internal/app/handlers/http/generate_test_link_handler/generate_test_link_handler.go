@@ -3,36 +3,29 @@ package generate_test_link_handler
 import (
 	"encoding/json"
 	"fmt"
-	rolesService "github.com/IT-Nick/internal/domain/roles/service"
 	testsService "github.com/IT-Nick/internal/domain/tests/service"
-	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
 	httpError "github.com/IT-Nick/pkg/http"
 	"github.com/google/uuid"
-	"github.com/skip2/go-qrcode"
 	"net/http"
-	"os"
+	"net/url"
+	"time"
 )
 
 // GenerateTestLinkHandler структура для обработчика
 type GenerateTestLinkHandler struct {
 	testService *testsService.TestService
-	userService *usersService.UserService
-	roleService *rolesService.RoleService
 	botUsername string
-	baseURL     string
+	baseURL     string // Внешний адрес HTTP сервера со схемой, на котором доступен маршрут GET /qr/{token}
 }
 
 // NewGenerateTestLinkHandler создает новый экземпляр обработчика
 func NewGenerateTestLinkHandler(
 	testService *testsService.TestService,
-	userService *usersService.UserService,
-	roleService *rolesService.RoleService,
 	botUsername, baseURL string,
 ) *GenerateTestLinkHandler {
 	return &GenerateTestLinkHandler{
 		testService: testService,
-		userService: userService,
-		roleService: roleService,
 		botUsername: botUsername,
 		baseURL:     baseURL,
 	}
@@ -51,40 +44,29 @@ func (h *GenerateTestLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Проверяем, что username и test_id указаны
-	if req.Username == "" || req.TestID <= 0 {
-		httpError.ErrorResponse(w, http.StatusBadRequest, "Missing username or test_id")
+	// Проверяем, что test_id указан
+	if req.TestID <= 0 {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Missing test_id")
 		return
 	}
-
-	// Проверяем, что пользователь существует
-	ctx := r.Context()
-	user, err := h.userService.GetUserByUsername(ctx, req.Username)
-	if err != nil || user == nil {
-		httpError.ErrorResponse(w, http.StatusUnauthorized, "User not found")
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "expires_at must be in the future")
 		return
 	}
-
-	// Проверяем, что пользователь имеет разрешение generate_qr
-	permissions, err := h.roleService.GetPermissionsForUser(ctx, user.RoleID)
-	if err != nil {
-		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve permissions")
+	if req.MaxUses != nil && *req.MaxUses <= 0 {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "max_uses must be positive")
 		return
 	}
-
-	hasGenerateQRPermission := false
-	for _, perm := range permissions {
-		if perm == "generate_qr" {
-			hasGenerateQRPermission = true
-			break
-		}
-	}
-
-	if !hasGenerateQRPermission {
-		httpError.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized: user does not have permission to generate test links")
+	if err := testsService.ValidateAvailabilityWindow(req.AvailabilityWindow); err != nil {
+		httpError.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	// Ссылка выпускается от имени пользователя, аутентифицированного по API ключу.
+	// Право generate_qr проверено AuthMiddleware.
+	ctx := r.Context()
+	user := middlewares.UserFromContext(ctx)
+
 	// Проверяем, существует ли тест
 	test, err := h.testService.GetTestByID(ctx, req.TestID)
 	if err != nil || test == nil {
@@ -95,41 +77,32 @@ func (h *GenerateTestLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	// Генерируем уникальный токен
 	token := uuid.New().String()
 
-	// Сохраняем токен в базе
-	err = h.testService.SaveTestLink(ctx, req.TestID, token)
+	// Сохраняем токен в базе вместе с автором ссылки: при переходе по ней HR определяется по этой записи
+	linkID, err := h.testService.SaveTestLink(ctx, req.TestID, token, user.ID, req.ExpiresAt, req.MaxUses, req.AvailabilityWindow)
 	if err != nil {
 		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to save test link")
 		return
 	}
 
-	// Формируем ссылку
-	link := fmt.Sprintf("https://t.me/%s?start=test_%d_%s_%s", h.botUsername, req.TestID, token, req.Username)
-
-	// Генерируем QR-код
-	qrCodeFilename := fmt.Sprintf("test_%d_%s_%s.png", req.TestID, token, req.Username)
-	qrCodePath := qrCodeFilename
-
-	err = qrcode.WriteFile(link, qrcode.Medium, 256, qrCodePath)
-	if err != nil {
-		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate QR code: %v", err))
-		return
-	}
-
-	// Формируем URL для скачивания QR-кода
-	qrCodeURL := fmt.Sprintf("%s/qr/%s", h.baseURL, qrCodeFilename)
+	// Формируем ссылку; QR-код по ней отрисовывается маршрутом GET /qr/{token}, адрес абсолютный для печати плакатов
+	link := testsService.TestDeepLink(h.botUsername, req.TestID, token)
+	qrCodeURL := fmt.Sprintf("%s/qr/%s", h.baseURL, url.PathEscape(token))
 
 	// Отправляем успешный ответ
 	response := GenerateTestLinkResponse{
+		LinkID:    linkID,
+		Token:     token,
 		Link:      link,
 		QRCodeURL: qrCodeURL,
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+
+		AvailabilityWindow: req.AvailabilityWindow,
 	}
-	w.WriteHeader(http.StatusOK)
 	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
 		return
 	}
-
-	// Удаляем временный файл QR-кода
-	os.Remove(qrCodePath)
 }
@@ -1,7 +1,16 @@
 package generate_test_link_handler
 
+import (
+	"github.com/IT-Nick/internal/domain/model"
+	"time"
+)
+
 // GenerateTestLinkRequest структура для данных запроса
 type GenerateTestLinkRequest struct {
-	TestID   int    `json:"test_id"`
-	Username string `json:"username"`
+	TestID    int        `json:"test_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // срок действия ссылки, по умолчанию бессрочная
+	MaxUses   *int       `json:"max_uses,omitempty"`   // лимит использований, по умолчанию без лимита
+
+	// Окно доступности (available_from, available_until): когда можно начать тест, назначенный по ссылке
+	model.AvailabilityWindow
 }
@@ -1,7 +1,18 @@
 package generate_test_link_handler
 
+import (
+	"github.com/IT-Nick/internal/domain/model"
+	"time"
+)
+
 // GenerateTestLinkResponse структура для ответа
 type GenerateTestLinkResponse struct {
-	Link      string `json:"link"`
-	QRCodeURL string `json:"qr_code_url"`
+	LinkID    int        `json:"link_id"`
+	Token     string     `json:"token"`
+	Link      string     `json:"link"`
+	QRCodeURL string     `json:"qr_code_url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+
+	model.AvailabilityWindow
 }
@@ -0,0 +1,107 @@
+package qr_code_handler
+
+import (
+	"errors"
+	"fmt"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"github.com/IT-Nick/internal/infra/qr"
+	httpError "github.com/IT-Nick/pkg/http"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// QRCodeHandler отдает QR-код ссылки на тест, отрисованный по записи test_links
+type QRCodeHandler struct {
+	testService *testsService.TestService
+	renderer    *qr.Renderer
+	botUsername string
+}
+
+// NewQRCodeHandler создает новый экземпляр обработчика
+func NewQRCodeHandler(testService *testsService.TestService, renderer *qr.Renderer, botUsername string) *QRCodeHandler {
+	return &QRCodeHandler{
+		testService: testService,
+		renderer:    renderer,
+		botUsername: botUsername,
+	}
+}
+
+// ServeHTTP обрабатывает GET /qr/{token}. Параметры запроса:
+// format - png (по умолчанию) или svg, size - размер в пикселях, level - коррекция ошибок L/M/Q/H,
+// logo=false - без логотипа компании.
+func (h *QRCodeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var opts qr.Options
+	if value := query.Get("size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			httpError.ErrorResponse(w, http.StatusBadRequest, "Invalid size")
+			return
+		}
+		opts.Size = size
+	}
+	opts.Level = query.Get("level")
+	if value := query.Get("logo"); value != "" {
+		withLogo, err := strconv.ParseBool(value)
+		if err != nil {
+			httpError.ErrorResponse(w, http.StatusBadRequest, "Invalid logo")
+			return
+		}
+		opts.NoLogo = !withLogo
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "format must be png or svg")
+		return
+	}
+
+	// QR-код отдается только для действующей ссылки
+	ctx := r.Context()
+	link, err := h.testService.GetActiveTestLink(ctx, r.PathValue("token"))
+	switch {
+	case errors.Is(err, testsService.ErrTestLinkNotFound):
+		httpError.ErrorResponse(w, http.StatusNotFound, "Test link not found")
+		return
+	case errors.Is(err, testsService.ErrTestLinkRevoked),
+		errors.Is(err, testsService.ErrTestLinkExpired),
+		errors.Is(err, testsService.ErrTestLinkExhausted):
+		httpError.ErrorResponse(w, http.StatusGone, fmt.Sprintf("Test link is no longer valid: %v", err))
+		return
+	case err != nil:
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get test link: %v", err))
+		return
+	}
+
+	content := testsService.TestDeepLink(h.botUsername, link.TestID, link.Token)
+
+	var image []byte
+	contentType := "image/png"
+	if format == "svg" {
+		image, err = h.renderer.SVG(content, opts)
+		contentType = "image/svg+xml"
+	} else {
+		image, err = h.renderer.PNG(content, opts)
+	}
+	if errors.Is(err, qr.ErrInvalidSize) || errors.Is(err, qr.ErrInvalidLevel) {
+		httpError.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate QR code: %v", err))
+		return
+	}
+
+	// Ссылку могут отозвать, поэтому изображение не кешируется
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(image); err != nil {
+		log.Printf("Failed to write QR code: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package revoke_test_link_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
+	httpError "github.com/IT-Nick/pkg/http"
+	"net/http"
+)
+
+// RevokeTestLinkRequest структура для данных запроса
+type RevokeTestLinkRequest struct {
+	LinkID int `json:"link_id"`
+}
+
+// RevokeTestLinkHandler структура для обработчика отзыва ссылки на тест
+type RevokeTestLinkHandler struct {
+	testService *testsService.TestService
+}
+
+// NewRevokeTestLinkHandler создает новый экземпляр обработчика
+func NewRevokeTestLinkHandler(testService *testsService.TestService) *RevokeTestLinkHandler {
+	return &RevokeTestLinkHandler{
+		testService: testService,
+	}
+}
+
+// ServeHTTP отзывает ссылку, выпущенную текущим пользователем
+func (h *RevokeTestLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request RevokeTestLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if request.LinkID <= 0 {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Missing link_id")
+		return
+	}
+
+	ctx := r.Context()
+	user := middlewares.UserFromContext(ctx)
+
+	err := h.testService.RevokeTestLink(ctx, request.LinkID, user.ID)
+	if errors.Is(err, testsService.ErrTestLinkNotFound) {
+		httpError.ErrorResponse(w, http.StatusNotFound, fmt.Sprintf("Active link %d not found", request.LinkID))
+		return
+	}
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to revoke link: %v", err))
+		return
+	}
+
+	// Отправляем успешный ответ
+	w.WriteHeader(http.StatusOK)
+	w.Header().Add("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"message": fmt.Sprintf("Link %d revoked", request.LinkID),
+		"link_id": request.LinkID,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
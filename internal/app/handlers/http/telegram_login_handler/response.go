@@ -0,0 +1,10 @@
+package telegram_login_handler
+
+import "time"
+
+// TelegramLoginResponse структура для ответа
+type TelegramLoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Username  string    `json:"username"`
+}
@@ -0,0 +1,97 @@
+package telegram_login_handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	authService "github.com/IT-Nick/internal/domain/auth/service"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
+	httpError "github.com/IT-Nick/pkg/http"
+	"net/http"
+)
+
+// TelegramLoginHandler структура для обработчика входа в HR панель через Telegram Login Widget
+type TelegramLoginHandler struct {
+	authService *authService.AuthService
+}
+
+// NewTelegramLoginHandler создает новый экземпляр обработчика
+func NewTelegramLoginHandler(authService *authService.AuthService) *TelegramLoginHandler {
+	return &TelegramLoginHandler{
+		authService: authService,
+	}
+}
+
+// ServeHTTP метод для обработки запроса. Данные виджета принимаются в query (data-auth-url)
+// или в JSON теле (data-onauth). В ответ выдается JWT сессии, он же устанавливается в cookie.
+func (h *TelegramLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fields, err := loginFields(r)
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, token, expiresAt, err := h.authService.LoginWithTelegram(r.Context(), fields)
+	switch {
+	case errors.Is(err, authService.ErrInvalidLogin), errors.Is(err, authService.ErrLoginExpired):
+		httpError.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, authService.ErrUnknownUser):
+		httpError.ErrorResponse(w, http.StatusForbidden, "User not found, press /start in the bot first")
+		return
+	case err != nil:
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to login: %v", err))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     middlewares.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	// Отправляем успешный ответ
+	response := TelegramLoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Username:  user.TelegramUsername,
+	}
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
+
+// loginFields извлекает поля Telegram Login Widget из query или JSON тела запроса
+func loginFields(r *http.Request) (map[string]string, error) {
+	fields := make(map[string]string)
+
+	if r.Method == http.MethodGet {
+		for key, values := range r.URL.Query() {
+			if len(values) > 0 {
+				fields[key] = values[0]
+			}
+		}
+		return fields, nil
+	}
+
+	// Числовые поля (id, auth_date) должны попасть в строку проверки без изменения формата
+	var body map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	for key, raw := range body {
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+		fields[key] = value
+	}
+	return fields, nil
+}
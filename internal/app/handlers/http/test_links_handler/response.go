@@ -0,0 +1,9 @@
+package test_links_handler
+
+import "github.com/IT-Nick/internal/domain/model"
+
+// TestLinksResponse структура для ответа
+type TestLinksResponse struct {
+	Total int              `json:"total"`
+	Links []model.TestLink `json:"links"`
+}
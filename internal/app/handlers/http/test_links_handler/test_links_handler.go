@@ -0,0 +1,57 @@
+package test_links_handler
+
+import (
+	"encoding/json"
+	"fmt"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
+	httpError "github.com/IT-Nick/pkg/http"
+	"net/http"
+	"strconv"
+)
+
+// TestLinksHandler структура для обработчика списка ссылок на тесты
+type TestLinksHandler struct {
+	testService *testsService.TestService
+}
+
+// NewTestLinksHandler создает новый экземпляр обработчика
+func NewTestLinksHandler(testService *testsService.TestService) *TestLinksHandler {
+	return &TestLinksHandler{
+		testService: testService,
+	}
+}
+
+// ServeHTTP возвращает ссылки, выпущенные текущим пользователем. Необязательный параметр test_id фильтрует по тесту.
+func (h *TestLinksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var testID int
+	if value := r.URL.Query().Get("test_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			httpError.ErrorResponse(w, http.StatusBadRequest, "Invalid test_id")
+			return
+		}
+		testID = id
+	}
+
+	ctx := r.Context()
+	user := middlewares.UserFromContext(ctx)
+
+	links, err := h.testService.GetTestLinksByCreator(ctx, user.ID, testID)
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get test links: %v", err))
+		return
+	}
+
+	// Отправляем успешный ответ
+	response := TestLinksResponse{
+		Total: len(links),
+		Links: links,
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+}
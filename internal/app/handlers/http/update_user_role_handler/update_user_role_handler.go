@@ -3,10 +3,13 @@ package update_user_role_handler
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
 	roleService "github.com/IT-Nick/internal/domain/roles/service"
 	"github.com/IT-Nick/internal/domain/users/service"
+	"github.com/IT-Nick/internal/infra/http/middlewares"
 	httpError "github.com/IT-Nick/pkg/http"
 	"net/http"
+	"slices"
 )
 
 // UpdateUserRoleRequest структура для данных запроса
@@ -39,14 +42,43 @@ func (h *UpdateUserRoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 
 	// Получаем роль по имени
-	role, err := h.roleService.GetRoleByRoleName(r.Context(), request.RoleName)
+	ctx := r.Context()
+	role, err := h.roleService.GetRoleByRoleName(ctx, request.RoleName)
 	if err != nil {
 		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find role: %v", err))
 		return
 	}
 
+	target, err := h.userService.GetUserByUsername(ctx, request.Username)
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find user: %v", err))
+		return
+	}
+	if target == nil {
+		httpError.ErrorResponse(w, http.StatusNotFound, fmt.Sprintf("User %s not found", request.Username))
+		return
+	}
+
+	// Назначать и снимать администраторов может только пользователь с правом assign_admin
+	adminRole, err := h.roleService.GetRoleByRoleName(ctx, model.RoleAdmin)
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find role: %v", err))
+		return
+	}
+	if role.ID == adminRole.ID || target.RoleID == adminRole.ID {
+		permissions, err := h.roleService.GetPermissionsForUser(ctx, middlewares.UserFromContext(ctx).RoleID)
+		if err != nil {
+			httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve permissions")
+			return
+		}
+		if !slices.Contains(permissions, model.PermissionAssignAdmin) {
+			httpError.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("Permission %s required", model.PermissionAssignAdmin))
+			return
+		}
+	}
+
 	// Обновляем роль пользователя
-	userID, err := h.userService.UpdateUserRole(r.Context(), request.Username, role.Name)
+	userID, err := h.userService.UpdateUserRole(ctx, request.Username, role.Name)
 	if err != nil {
 		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update user role: %v", err))
 		return
@@ -64,7 +64,7 @@ func (h *UserTestReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 
 	// Формируем полный отчет
-	fullName := fmt.Sprintf("%s %s %s", user.RealFirstName, user.RealSecondName, user.RealSurname)
+	fullName := user.FullName()
 	response := dto.UserTestReportResponse{
 		Username:    request.Username,
 		TelegramID:  *user.TelegramID,
@@ -3,77 +3,58 @@ package answer_handler
 import (
 	"context"
 	"fmt"
+	"github.com/IT-Nick/internal/app/handlers/telegram/question_sender"
 	"github.com/IT-Nick/internal/domain/model"
 	testsService "github.com/IT-Nick/internal/domain/tests/service"
 	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"github.com/IT-Nick/internal/infra/timer"
 	"gopkg.in/telebot.v4"
 	"log"
-	"strconv"
 	"strings"
-	"time"
 )
 
 type AnswerHandler struct {
-	bot         *telebot.Bot
-	testService *testsService.TestService
-	userService *usersService.UserService
+	bot            *telebot.Bot
+	testService    *testsService.TestService
+	userService    *usersService.UserService
+	questionSender *question_sender.QuestionSender
+	signer         *question_sender.CallbackSigner
+	timerUpdater   *timer.Updater
 }
 
 func NewAnswerHandler(
 	bot *telebot.Bot,
 	testService *testsService.TestService,
 	userService *usersService.UserService,
+	timerUpdater *timer.Updater,
+	signer *question_sender.CallbackSigner,
 ) *AnswerHandler {
 	return &AnswerHandler{
-		bot:         bot,
-		testService: testService,
-		userService: userService,
+		bot:            bot,
+		testService:    testService,
+		userService:    userService,
+		questionSender: question_sender.NewQuestionSender(bot, signer),
+		signer:         signer,
+		timerUpdater:   timerUpdater,
 	}
 }
 
-// sendQuestion отправляет вопрос пользователю с порядковым номером и общим количеством вопросов
-func (h *AnswerHandler) sendQuestion(recipient *telebot.User, question model.Question, questionNumber int) error {
-	// Формируем текст вопроса с порядковым номером и общим количеством вопросов
-	var messageBuilder strings.Builder
-	messageBuilder.WriteString(fmt.Sprintf("❓ *Вопрос %d:*\n%s\n\n", questionNumber, question.QuestionText))
-
-	// Формируем клавиатуру с вариантами ответа
-	var buttons []telebot.Btn
-	var markup *telebot.ReplyMarkup
-	markup = h.bot.NewMarkup()
-
-	if question.TestOptions != nil && len(question.TestOptions) > 0 {
-		for i, option := range question.TestOptions {
-			btnText := fmt.Sprintf("%d. %s", i+1, option)
-			// Используем question.ID для callbackData, чтобы сохранить уникальность
-			callbackData := fmt.Sprintf("answer_%d_%d_%s", question.ID, i, option)
-			btn := markup.Data(btnText, callbackData)
-			buttons = append(buttons, btn)
-		}
-
-		// Создаем инлайн-клавиатуру
-		rows := make([]telebot.Row, 0)
-		for _, btn := range buttons {
-			rows = append(rows, markup.Row(btn))
-		}
-		markup.Inline(rows...)
-	}
-
-	// Отправляем сообщение с вопросом
-	_, err := h.bot.Send(recipient, messageBuilder.String(), &telebot.SendOptions{
-		ParseMode:   telebot.ModeMarkdown,
-		ReplyMarkup: markup,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to send question: %w", err)
-	}
+// testProgress текущее состояние прохождения теста кандидатом
+type testProgress struct {
+	userTestID           int
+	currentQuestionIndex int
+	questions            []model.Question
+	finished             bool
+	freeNavigation       bool                 // Кандидат может пропускать вопросы и менять ответы до отправки
+	answers              map[int]model.Answer // Сохраненные ответы по ID вопроса, только в режиме свободной навигации
+}
 
-	return nil
+// currentQuestion возвращает вопрос, на который кандидат должен ответить сейчас
+func (p *testProgress) currentQuestion() model.Question {
+	return p.questions[p.currentQuestionIndex]
 }
 
 func (h *AnswerHandler) Handle(c telebot.Context) error {
-	telegramID := c.Sender().ID
-	username := c.Sender().Username
 	callbackData := c.Callback().Data
 
 	// Очищаем callbackData от нестандартных символов
@@ -81,143 +62,377 @@ func (h *AnswerHandler) Handle(c telebot.Context) error {
 	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
 	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
 
-	// Проверяем, что callback начинается с "answer_"
-	if !strings.HasPrefix(cleanedData, "answer_") {
-		return nil
+	if strings.HasPrefix(cleanedData, "nav_") {
+		return h.handleNavigation(c, cleanedData)
+	}
+
+	// Кнопки ответа подписаны: ID назначения, вопрос и вариант принимаются только из данных, выпущенных ботом
+	action, callback, err := h.signer.Decode(cleanedData)
+	if err != nil {
+		return h.rejectCallback(c)
+	}
+
+	switch action {
+	case question_sender.ActionAnswer:
+		return h.handleSingle(c, callback)
+	case question_sender.ActionToggle:
+		return h.handleToggle(c, callback)
+	case question_sender.ActionConfirm:
+		return h.handleConfirm(c, callback)
+	}
+
+	return nil
+}
+
+// handleSingle обрабатывает ответ на вопрос с одним правильным ответом.
+// callback.Option - индекс варианта в TestOptions, а не позиция кнопки, поэтому перемешивание вариантов не влияет на проверку ответа.
+func (h *AnswerHandler) handleSingle(c telebot.Context, callback question_sender.AnswerCallback) error {
+	ctx := context.Background()
+	progress, err := h.loadCallbackProgress(ctx, c, callback)
+	if err != nil || progress == nil {
+		return err
+	}
+	currentQuestion := progress.currentQuestion()
+
+	optionIndex := callback.Option
+	if optionIndex < 0 || optionIndex >= len(currentQuestion.TestOptions) || optionIndex >= question_sender.MaxMultipleOptions {
+		return fmt.Errorf("option index %d out of range for question %d", optionIndex, callback.QuestionID)
+	}
+
+	// Сохраняем и проверяем исходный вариант, а не то, что видел кандидат на экране
+	answerText := currentQuestion.TestOptions[optionIndex]
+	isCorrect := answerText == currentQuestion.CorrectAnswer
+
+	return h.submitAnswer(ctx, c, progress, answerText, isCorrect, false)
+}
+
+// handleToggle отмечает или снимает отметку с варианта в вопросе с несколькими ответами
+func (h *AnswerHandler) handleToggle(c telebot.Context, callback question_sender.AnswerCallback) error {
+	ctx := context.Background()
+	progress, err := h.loadCallbackProgress(ctx, c, callback)
+	if err != nil || progress == nil {
+		return err
+	}
+	currentQuestion := progress.currentQuestion()
+
+	optionIndex := callback.Option
+	if optionIndex < 0 || optionIndex >= len(currentQuestion.TestOptions) || optionIndex >= question_sender.MaxMultipleOptions {
+		return fmt.Errorf("option index %d out of range for question %d", optionIndex, callback.QuestionID)
+	}
+
+	// Переключаем вариант и перерисовываем клавиатуру с отметками
+	selected := callback.Selected ^ 1<<uint(optionIndex)
+
+	// Обновление обратного отсчета перерисовывает вопрос, поэтому отметки сохраняются в базе
+	if currentQuestion.TimeLimitSeconds != nil && !progress.freeNavigation {
+		err = h.testService.SaveQuestionSelection(ctx, progress.userTestID, selected)
+		if err != nil {
+			return fmt.Errorf("failed to save selected options: %w", err)
+		}
+	}
+	markup, err := h.questionSender.MultipleMarkup(progress.userTestID, currentQuestion, selected)
+	if err != nil {
+		return err
+	}
+	if progress.freeNavigation {
+		h.questionSender.AddNavigation(markup, progress.navigation(progress.currentQuestionIndex))
+	}
+	_, err = h.bot.EditReplyMarkup(c.Message(), markup)
+	if err != nil {
+		return fmt.Errorf("failed to update options keyboard: %w", err)
+	}
+
+	return c.Respond()
+}
+
+// handleConfirm принимает набор вариантов, отмеченных в вопросе с несколькими ответами
+func (h *AnswerHandler) handleConfirm(c telebot.Context, callback question_sender.AnswerCallback) error {
+	selected := callback.Selected
+	if selected == 0 {
+		return c.Respond(&telebot.CallbackResponse{
+			Text: "Отметьте хотя бы один вариант ответа.",
+		})
 	}
 
-	// Парсим callback данные (answer_questionID_optionIndex_answerText)
-	parts := strings.Split(cleanedData, "_")
-	if len(parts) < 4 {
-		return fmt.Errorf("invalid callback data: %s", callbackData)
+	ctx := context.Background()
+	progress, err := h.loadCallbackProgress(ctx, c, callback)
+	if err != nil || progress == nil {
+		return err
+	}
+	currentQuestion := progress.currentQuestion()
+
+	var optionIndexes []int
+	for i := range currentQuestion.TestOptions {
+		if selected&(1<<uint(i)) != 0 {
+			optionIndexes = append(optionIndexes, i)
+		}
 	}
 
-	questionIDStr := parts[1]                  // questionID
-	optionIndexStr := parts[2]                 // optionIndex
-	answerText := strings.Join(parts[3:], "_") // answerText
+	userAnswer, isCorrect, err := h.testService.CheckMultipleAnswer(currentQuestion, optionIndexes)
+	if err != nil {
+		return fmt.Errorf("failed to check answer: %w", err)
+	}
+
+	return h.submitAnswer(ctx, c, progress, userAnswer, isCorrect, false)
+}
+
+// rejectCallback отвечает на кнопку с неверной подписью. Такие кнопки остаются в вопросах, отправленных
+// до подписи callback данных или с другим ключом, поэтому кандидату заново показывается текущий вопрос.
+func (h *AnswerHandler) rejectCallback(c telebot.Context) error {
+	log.Printf("Rejected answer callback with invalid signature from user %d", c.Sender().ID)
 
-	questionID, err := strconv.Atoi(questionIDStr)
+	ctx := context.Background()
+	userTestID, err := h.testService.GetUserTestIDByUserID(ctx, c.Sender().ID)
 	if err != nil {
-		return fmt.Errorf("invalid question ID: %w", err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Кнопка недействительна."})
 	}
 
-	optionIndex, err := strconv.Atoi(optionIndexStr)
+	progress, err := h.loadProgress(ctx, userTestID)
+	if err != nil || progress == nil {
+		return err
+	}
+	if progress.finished {
+		return c.Respond(&telebot.CallbackResponse{Text: "Тест уже завершен."})
+	}
+	if progress.freeNavigation {
+		return h.showQuestion(ctx, c, progress, progress.currentQuestionIndex)
+	}
+
+	err = h.bot.Delete(c.Message())
+	if err != nil {
+		log.Printf("Failed to delete question with invalid buttons for user %d: %v", c.Sender().ID, err)
+	}
+	err = h.sendQuestion(ctx, c.Sender(), userTestID, progress.currentQuestion(), progress.currentQuestionIndex)
 	if err != nil {
-		return fmt.Errorf("invalid option index: %w", err)
+		return fmt.Errorf("failed to resend question: %w", err)
 	}
 
-	// Получаем текущее состояние теста из базы
+	return c.Respond(&telebot.CallbackResponse{Text: "Кнопка устарела, вопрос отправлен заново."})
+}
+
+// HandleText принимает свободный ответ кандидата на текущий вопрос с типом "text".
+// Возвращает false, если у отправителя нет теста в процессе прохождения и сообщение
+// нужно передать другому обработчику текста.
+func (h *AnswerHandler) HandleText(c telebot.Context) (bool, error) {
 	ctx := context.Background()
-	userTestID, err := h.testService.GetUserTestIDByUserID(ctx, telegramID)
+	userTestID, err := h.testService.GetUserTestIDByUserID(ctx, c.Sender().ID)
 	if err != nil {
-		return c.Send("Тест не найден. Пожалуйста, начните тест заново.")
+		return false, nil
 	}
 
-	currentQuestionIndex, correctAnswersCount, status, err := h.testService.GetUserTestState(ctx, userTestID)
+	progress, err := h.loadProgress(ctx, userTestID)
 	if err != nil {
-		return fmt.Errorf("failed to get user test state: %w", err)
+		return true, err
+	}
+	if progress == nil || progress.finished {
+		return false, nil
 	}
 
-	if status == "finished" {
-		return c.Send("Тест уже завершен.")
+	currentQuestion := progress.currentQuestion()
+	if currentQuestion.AnswerType != model.AnswerTypeText {
+		return true, c.Send("Выберите вариант ответа с помощью кнопок под вопросом.")
+	}
+
+	answer := strings.TrimSpace(c.Message().Text)
+	if answer == "" {
+		return true, c.Send("Ответ не может быть пустым.")
+	}
+
+	// Ответы, которые бот не может проверить сам, уходят в очередь ручной проверки
+	if testsService.NeedsManualReview(currentQuestion) {
+		return true, h.submitAnswer(ctx, c, progress, answer, false, true)
+	}
+
+	isCorrect, err := h.testService.CheckTextAnswer(currentQuestion, answer)
+	if err != nil {
+		log.Printf("Failed to auto-grade answer for question %d, sending to manual review: %v", currentQuestion.ID, err)
+		return true, h.submitAnswer(ctx, c, progress, answer, false, true)
+	}
+
+	return true, h.submitAnswer(ctx, c, progress, answer, isCorrect, false)
+}
+
+// loadCallbackProgress получает текущее состояние теста и проверяет, что callback относится к назначению кандидата
+// и к текущему вопросу. Возвращает nil без ошибки, если кандидату уже отправлено сообщение о том, что ответ не может быть принят.
+func (h *AnswerHandler) loadCallbackProgress(ctx context.Context, c telebot.Context, callback question_sender.AnswerCallback) (*testProgress, error) {
+	userTestID, err := h.testService.GetUserTestIDByUserID(ctx, c.Sender().ID)
+	if err != nil {
+		return nil, c.Send("Тест не найден. Пожалуйста, начните тест заново.")
+	}
+
+	// Кнопка из вопроса другого назначения: пересланное сообщение или тест, пройденный ранее
+	if callback.UserTestID != userTestID {
+		return nil, c.Respond(&telebot.CallbackResponse{Text: "Этот вопрос не относится к вашему текущему тесту."})
+	}
+
+	progress, err := h.loadProgress(ctx, userTestID)
+	if err != nil || progress == nil {
+		return nil, err
+	}
+	if progress.finished {
+		return nil, c.Send("Тест уже завершен.")
+	}
+
+	questionID := callback.QuestionID
+
+	// В режиме свободной навигации кандидат может ответить на любой вопрос теста, а не только на текущий
+	if progress.freeNavigation && progress.currentQuestion().ID != questionID {
+		for i, question := range progress.questions {
+			if question.ID == questionID {
+				progress.currentQuestionIndex = i
+				break
+			}
+		}
+	}
+
+	// Проверяем, что текущий вопрос соответствует callback
+	if progress.currentQuestion().ID != questionID {
+		return nil, fmt.Errorf("mismatch between current question ID %d and callback question ID %d", progress.currentQuestion().ID, questionID)
+	}
+
+	return progress, nil
+}
+
+// loadProgress получает текущее состояние теста из базы
+func (h *AnswerHandler) loadProgress(ctx context.Context, userTestID int) (*testProgress, error) {
+	currentQuestionIndex, _, status, err := h.testService.GetUserTestState(ctx, userTestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user test state: %w", err)
+	}
+
+	if status != model.UserTestStatusInProgress {
+		return &testProgress{userTestID: userTestID, finished: true}, nil
 	}
 
 	// Получаем выбранные вопросы теста
 	selectedQuestions, err := h.testService.GetSelectedQuestions(ctx, userTestID)
 	if err != nil {
-		return fmt.Errorf("failed to get selected questions: %w", err)
+		return nil, fmt.Errorf("failed to get selected questions: %w", err)
 	}
 
 	// Проверяем, есть ли вопросы
 	if len(selectedQuestions) == 0 {
-		return fmt.Errorf("no selected questions found for user test ID %d", userTestID)
+		return nil, fmt.Errorf("no selected questions found for user test ID %d", userTestID)
 	}
 
 	// Проверяем, что currentQuestionIndex валиден
 	if currentQuestionIndex < 0 || currentQuestionIndex >= len(selectedQuestions) {
-		return fmt.Errorf("invalid current question index: %d, total questions: %d", currentQuestionIndex, len(selectedQuestions))
+		return nil, fmt.Errorf("invalid current question index: %d, total questions: %d", currentQuestionIndex, len(selectedQuestions))
+	}
+
+	freeNavigation, err := h.testService.IsFreeNavigation(ctx, userTestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get navigation mode: %w", err)
 	}
 
-	currentQuestion := selectedQuestions[currentQuestionIndex]
+	progress := &testProgress{
+		userTestID:           userTestID,
+		currentQuestionIndex: currentQuestionIndex,
+		questions:            selectedQuestions,
+		freeNavigation:       freeNavigation,
+	}
 
-	// Проверяем, что текущий вопрос соответствует callback
-	if currentQuestion.ID != questionID {
-		return fmt.Errorf("mismatch between current question ID %d and callback question ID %d", currentQuestion.ID, questionID)
+	if freeNavigation {
+		progress.answers, err = h.testService.GetAnswersByQuestion(ctx, userTestID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get answers: %w", err)
+		}
 	}
 
-	// Проверяем правильность ответа
-	isCorrect := false
-	if len(currentQuestion.TestOptions) > optionIndex {
-		// Предполагаем, что правильный ответ хранится в вопросе (например, в отдельном поле CorrectAnswer)
-		isCorrect = currentQuestion.TestOptions[optionIndex] == currentQuestion.CorrectAnswer // Заглушка
+	return progress, nil
+}
+
+// submitAnswer сохраняет ответ на текущий вопрос и переходит к следующему вопросу или завершает тест.
+// needsReview - ответ будет оценен менеджером вручную и пока не учитывается в correct_answers_count.
+func (h *AnswerHandler) submitAnswer(ctx context.Context, c telebot.Context, progress *testProgress, userAnswer string, isCorrect bool, needsReview bool) error {
+	if progress.freeNavigation {
+		return h.submitNavigableAnswer(ctx, c, progress, userAnswer, isCorrect, needsReview)
 	}
 
-	// Сохраняем ответ в таблицу answers
-	err = h.testService.SaveAnswer(ctx, userTestID, questionID, answerText, isCorrect)
+	userTestID := progress.userTestID
+	currentQuestion := progress.currentQuestion()
+
+	// Сохраняем ответ и переходим к следующему вопросу. Если время на вопрос истекло раньше, таймер уже засчитал его
+	// без ответа и отправил следующий вопрос, поэтому опоздавший ответ не сохраняется
+	saved, err := h.testService.SaveAnswerAndAdvance(ctx, userTestID, progress.currentQuestionIndex, currentQuestion.ID, userAnswer, isCorrect, needsReview)
 	if err != nil {
 		return fmt.Errorf("failed to save answer: %w", err)
 	}
+	if !saved {
+		if c.Callback() != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: "Ответ не принят: вопрос уже закрыт."})
+		}
+		return c.Send("Ответ не принят: вопрос уже закрыт.")
+	}
+	currentQuestionIndex := progress.currentQuestionIndex + 1
 
-	// Обновляем correct_answers_count
-	if isCorrect {
-		correctAnswersCount++
+	// Удаляем предыдущее сообщение с вопросом. Текстовый ответ приходит отдельным сообщением,
+	// поэтому в этом случае вопрос и ответ остаются в переписке.
+	if c.Callback() != nil {
+		err = h.bot.Delete(c.Message())
+		if err != nil {
+			return fmt.Errorf("failed to delete previous question: %w", err)
+		}
 	}
 
-	// Увеличиваем current_question_index
-	currentQuestionIndex++
+	// Проверяем, есть ли следующий вопрос
+	if currentQuestionIndex >= len(progress.questions) {
+		return h.finishTest(ctx, c, userTestID)
+	}
 
-	// Обновляем состояние теста в базе
-	err = h.testService.UpdateUserTestState(ctx, userTestID, currentQuestionIndex, correctAnswersCount)
+	// Отправляем следующий вопрос с порядковым номером
+	nextQuestion := progress.questions[currentQuestionIndex]
+	err = h.sendQuestion(ctx, c.Sender(), userTestID, nextQuestion, currentQuestionIndex)
 	if err != nil {
-		return fmt.Errorf("failed to update user test state: %w", err)
+		return fmt.Errorf("failed to send next question: %w", err)
 	}
 
-	// Удаляем предыдущее сообщение с вопросом
-	err = h.bot.Delete(c.Message())
+	return nil
+}
+
+// finishTest завершает тест, пересчитывает баллы по сохраненным ответам и уведомляет назначившего тест HR
+func (h *AnswerHandler) finishTest(ctx context.Context, c telebot.Context, userTestID int) error {
+	username := c.Sender().Username
+
+	status, err := h.testService.FinishUserTest(ctx, userTestID)
 	if err != nil {
-		return fmt.Errorf("failed to delete previous question: %w", err)
+		return fmt.Errorf("failed to finish test: %w", err)
+	}
+	if status == "" {
+		// Тест уже завершен таймером
+		return c.Send("Тест уже завершен.")
 	}
 
-	// Проверяем, есть ли следующий вопрос
-	if currentQuestionIndex >= len(selectedQuestions) {
-		// Тест завершен
-		err = h.testService.UpdateUserTestStatus(ctx, userTestID, "finished")
-		err = h.testService.UpdateUserTestEndTime(ctx, userTestID, time.Now())
-
-		// Получаем пользователя, который назначил тест
-		userTest, err := h.userService.GetUserTestByID(ctx, userTestID)
-		if err != nil {
-			return c.Respond(&telebot.CallbackResponse{
-				Text: fmt.Sprintf("Ошибка при получении информации о назначившем пользователе: %v", err),
-			})
-		}
-		assignedByTgId, err := h.userService.GetUserByID(ctx, userTest.AssignedBy)
-		if err != nil {
-			return c.Respond(&telebot.CallbackResponse{
-				Text: fmt.Sprintf("Ошибка при получении информации о назначившем пользователе: %v", err),
-			})
-		}
-		test, err := h.testService.GetLastTestForUserWithFinishStatus(ctx, username)
-		if err != nil {
-			log.Printf("failed to get last test for user %s: %v", username, err)
+	// Получаем пользователя, который назначил тест
+	userTest, err := h.userService.GetUserTestByID(ctx, userTestID)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{
+			Text: fmt.Sprintf("Ошибка при получении информации о назначившем пользователе: %v", err),
+		})
+	}
+	assignedByTgId, err := h.userService.GetUserByID(ctx, userTest.AssignedBy)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{
+			Text: fmt.Sprintf("Ошибка при получении информации о назначившем пользователе: %v", err),
+		})
+	}
+	test, err := h.testService.GetTestByID(ctx, userTest.TestID)
+	if err != nil {
+		log.Printf("failed to get test %d for user %s: %v", userTest.TestID, username, err)
+	}
+	// Отправляем сообщение о завершении теста пользователю assigned_by
+	if test != nil && assignedByTgId.TelegramID != nil {
+		finishMessage := fmt.Sprintf("⚡️ Кандидат *%s* завершил выполнение теста *%s*.", username, test.TestName)
+		if status == model.UserTestStatusPendingReview {
+			finishMessage += "\nЧасть ответов ожидает ручной проверки, итоговый балл будет доступен после нее."
 		}
-		// Отправляем сообщение о завершении теста пользователю assigned_by
-		_, err = h.bot.Send(&telebot.User{ID: *assignedByTgId.TelegramID}, fmt.Sprintf("⚡️ Кандидат *%s* завершил выполнение теста *%s*.", username, test.TestName), &telebot.SendOptions{
+		_, err = h.bot.Send(&telebot.User{ID: *assignedByTgId.TelegramID}, finishMessage, &telebot.SendOptions{
 			ParseMode: telebot.ModeMarkdown,
 		})
-
 		if err != nil {
-			return fmt.Errorf("failed to update test status: %w", err)
+			log.Printf("Failed to notify assigned_by user: %v", err)
 		}
-		return c.Send("Тест завершен! Ваши ответы сохранены.")
 	}
 
-	// Отправляем следующий вопрос с порядковым номером и общим количеством вопросов
-	nextQuestion := selectedQuestions[currentQuestionIndex]
-	err = h.sendQuestion(c.Sender(), nextQuestion, currentQuestionIndex+1)
-	if err != nil {
-		return fmt.Errorf("failed to send next question: %w", err)
-	}
-
-	return nil
+	return c.Send("Тест завершен! Ваши ответы сохранены.")
 }
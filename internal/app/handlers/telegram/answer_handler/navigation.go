@@ -0,0 +1,221 @@
+package answer_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/app/handlers/telegram/question_sender"
+	"github.com/IT-Nick/internal/domain/model"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"gopkg.in/telebot.v4"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	summaryButtonsPerRow = 5  // Кнопок с номерами вопросов в одной строке списка вопросов
+	summaryPreviewLength = 40 // Длина начала текста вопроса в списке вопросов
+)
+
+// navigation возвращает положение вопроса в тесте и сохраненный ответ на него
+func (p *testProgress) navigation(index int) question_sender.Navigation {
+	nav := question_sender.Navigation{Index: index, Total: len(p.questions)}
+
+	question := p.questions[index]
+	answer, ok := p.answers[question.ID]
+	if !ok {
+		return nav
+	}
+
+	if question.AnswerType == model.AnswerTypeText {
+		nav.Answer = answer.UserAnswer
+		return nav
+	}
+
+	// Ответ хранится исходными вариантами, а показывается так, как варианты подписаны у кандидата.
+	// Для вопроса с несколькими ответами восстанавливаем отметки, чтобы кандидат изменял уже выбранный набор.
+	values := []string{answer.UserAnswer}
+	if question.AnswerType == model.AnswerTypeMultiple {
+		values = testsService.ParseAnswerSet(answer.UserAnswer)
+	}
+	var shown []string
+	for i, option := range question.TestOptions {
+		if !slices.Contains(values, option) {
+			continue
+		}
+		shown = append(shown, question_sender.OptionText(question, i))
+		if question.AnswerType == model.AnswerTypeMultiple {
+			nav.Selected |= 1 << uint(i)
+		}
+	}
+	nav.Answer = strings.Join(shown, ", ")
+	return nav
+}
+
+// unansweredCount возвращает количество вопросов, оставшихся без ответа
+func (p *testProgress) unansweredCount() int {
+	count := 0
+	for _, question := range p.questions {
+		if _, ok := p.answers[question.ID]; !ok {
+			count++
+		}
+	}
+	return count
+}
+
+// handleNavigation обрабатывает кнопки теста со свободной навигацией:
+// nav_goto_<индекс> открывает вопрос, nav_summary - список вопросов, nav_submit и nav_submitok отправляют тест
+func (h *AnswerHandler) handleNavigation(c telebot.Context, data string) error {
+	ctx := context.Background()
+	userTestID, err := h.testService.GetUserTestIDByUserID(ctx, c.Sender().ID)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Тест не найден. Пожалуйста, начните тест заново."})
+	}
+
+	progress, err := h.loadProgress(ctx, userTestID)
+	if err != nil || progress == nil {
+		return err
+	}
+	if progress.finished {
+		return c.Respond(&telebot.CallbackResponse{Text: "Тест уже завершен."})
+	}
+	if !progress.freeNavigation {
+		return c.Respond(&telebot.CallbackResponse{Text: "В этом тесте вопросы проходятся по порядку."})
+	}
+
+	switch {
+	case data == "nav_summary":
+		return h.showSummary(c, progress)
+	case data == "nav_submit":
+		return h.submitTest(ctx, c, progress, false)
+	case data == "nav_submitok":
+		return h.submitTest(ctx, c, progress, true)
+	case strings.HasPrefix(data, "nav_goto_"):
+		index, err := strconv.Atoi(strings.TrimPrefix(data, "nav_goto_"))
+		if err != nil || index < 0 || index >= len(progress.questions) {
+			return fmt.Errorf("invalid callback data: %s", data)
+		}
+		return h.showQuestion(ctx, c, progress, index)
+	}
+
+	return fmt.Errorf("invalid callback data: %s", data)
+}
+
+// submitNavigableAnswer сохраняет ответ в режиме свободной навигации и открывает следующий вопрос.
+// Баллы не начисляются до отправки теста, ответ можно изменить, открыв вопрос еще раз.
+func (h *AnswerHandler) submitNavigableAnswer(ctx context.Context, c telebot.Context, progress *testProgress, userAnswer string, isCorrect bool, needsReview bool) error {
+	currentQuestion := progress.currentQuestion()
+
+	err := h.testService.ReplaceAnswer(ctx, progress.userTestID, currentQuestion.ID, userAnswer, isCorrect, needsReview)
+	if err != nil {
+		return fmt.Errorf("failed to save answer: %w", err)
+	}
+	progress.answers[currentQuestion.ID] = model.Answer{QuestionID: currentQuestion.ID, UserAnswer: userAnswer}
+
+	next := progress.currentQuestionIndex + 1
+	if next >= len(progress.questions) {
+		return h.showSummary(c, progress)
+	}
+	return h.showQuestion(ctx, c, progress, next)
+}
+
+// showQuestion открывает вопрос по индексу: при нажатии кнопки заменяет текущее сообщение,
+// после текстового ответа отправляет вопрос новым сообщением
+func (h *AnswerHandler) showQuestion(ctx context.Context, c telebot.Context, progress *testProgress, index int) error {
+	err := h.testService.UpdateCurrentQuestion(ctx, progress.userTestID, index)
+	if err != nil {
+		return fmt.Errorf("failed to update current question: %w", err)
+	}
+	progress.currentQuestionIndex = index
+
+	question := progress.currentQuestion()
+	nav := progress.navigation(index)
+	if c.Callback() == nil {
+		return h.questionSender.SendNavigable(c.Sender(), progress.userTestID, question, nav)
+	}
+
+	err = h.questionSender.EditNavigable(c.Message(), progress.userTestID, question, nav)
+	if err != nil && !errors.Is(err, telebot.ErrSameMessageContent) && !errors.Is(err, telebot.ErrMessageNotModified) {
+		return err
+	}
+	return c.Respond()
+}
+
+// showSummary показывает список вопросов с отметками об ответах и кнопку отправки теста
+func (h *AnswerHandler) showSummary(c telebot.Context, progress *testProgress) error {
+	var text strings.Builder
+	text.WriteString("📋 Ваши ответы\n\n")
+
+	markup := h.bot.NewMarkup()
+	var rows []telebot.Row
+	var row telebot.Row
+	for i, question := range progress.questions {
+		mark := "⬜️"
+		if _, ok := progress.answers[question.ID]; ok {
+			mark = "✅"
+		}
+		text.WriteString(fmt.Sprintf("%s %d. %s\n", mark, i+1, preview(question.QuestionText)))
+
+		row = append(row, markup.Data(fmt.Sprintf("%s %d", mark, i+1), fmt.Sprintf("nav_goto_%d", i)))
+		if len(row) == summaryButtonsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, markup.Row(markup.Data("📨 Отправить тест", "nav_submit")))
+	markup.Inline(rows...)
+
+	total := len(progress.questions)
+	text.WriteString(fmt.Sprintf("\nОтвечено: %d из %d. Откройте вопрос, чтобы ответить или изменить ответ, "+
+		"или отправьте тест на проверку.", total-progress.unansweredCount(), total))
+
+	return h.showScreen(c, text.String(), markup)
+}
+
+// submitTest отправляет тест со свободной навигацией. Если остались вопросы без ответа,
+// сначала запрашивается подтверждение (confirmed = false).
+func (h *AnswerHandler) submitTest(ctx context.Context, c telebot.Context, progress *testProgress, confirmed bool) error {
+	if unanswered := progress.unansweredCount(); unanswered > 0 && !confirmed {
+		markup := h.bot.NewMarkup()
+		markup.Inline(
+			markup.Row(markup.Data("📨 Отправить", "nav_submitok")),
+			markup.Row(markup.Data("◀️ К вопросам", "nav_summary")),
+		)
+		text := fmt.Sprintf("Без ответа осталось вопросов: %d. Отправить тест? После отправки ответы изменить нельзя.", unanswered)
+		return h.showScreen(c, text, markup)
+	}
+
+	err := h.bot.Delete(c.Message())
+	if err != nil {
+		return fmt.Errorf("failed to delete test summary: %w", err)
+	}
+
+	return h.finishTest(ctx, c, progress.userTestID)
+}
+
+// showScreen показывает экран теста: при нажатии кнопки заменяет текущее сообщение, иначе отправляет новое
+func (h *AnswerHandler) showScreen(c telebot.Context, text string, markup *telebot.ReplyMarkup) error {
+	if c.Callback() == nil {
+		return c.Send(text, markup)
+	}
+
+	_, err := h.bot.Edit(c.Message(), text, markup)
+	if err != nil && !errors.Is(err, telebot.ErrSameMessageContent) && !errors.Is(err, telebot.ErrMessageNotModified) {
+		return fmt.Errorf("failed to edit test screen: %w", err)
+	}
+	return c.Respond()
+}
+
+// preview возвращает начало текста вопроса для списка вопросов
+func preview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= summaryPreviewLength {
+		return text
+	}
+	return string(runes[:summaryPreviewLength]) + "…"
+}
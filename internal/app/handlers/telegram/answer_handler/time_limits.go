@@ -0,0 +1,81 @@
+package answer_handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"gopkg.in/telebot.v4"
+	"log"
+	"time"
+)
+
+// sendQuestion отправляет вопрос и запоминает сообщение с ним.
+// Для вопроса с ограничением времени планировщик таймеров сразу начинает обратный отсчет.
+func (h *AnswerHandler) sendQuestion(ctx context.Context, recipient *telebot.User, userTestID int, question model.Question, index int) error {
+	message, err := h.questionSender.Send(recipient, userTestID, question, index+1)
+	if err != nil {
+		return err
+	}
+
+	err = h.testService.SaveQuestionMessageID(ctx, userTestID, message.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save question message ID: %w", err)
+	}
+
+	if question.TimeLimitSeconds != nil {
+		h.timerUpdater.Wake(userTestID)
+	}
+	return nil
+}
+
+// ShowCountdown обновляет обратный отсчет в сообщении с текущим вопросом (timer.QuestionPresenter)
+func (h *AnswerHandler) ShowCountdown(ctx context.Context, telegramID int64, userTestID int, progress model.UserTestProgress, timeLeft time.Duration) error {
+	question, err := h.testService.GetSelectedQuestion(ctx, userTestID, progress.QuestionID)
+	if err != nil {
+		return fmt.Errorf("failed to get question: %w", err)
+	}
+
+	message := &telebot.Message{ID: progress.QuestionMessageID, Chat: &telebot.Chat{ID: telegramID}}
+	return h.questionSender.EditCountdown(message, userTestID, *question, progress.CurrentQuestionIndex+1, progress.QuestionSelection, timeLeft)
+}
+
+// ExpireQuestion засчитывает вопрос, время на который истекло, без ответа и отправляет следующий вопрос (timer.QuestionPresenter).
+// Возвращает true, если вопрос был последним и тест нужно завершить.
+func (h *AnswerHandler) ExpireQuestion(ctx context.Context, telegramID int64, userTestID int, progress model.UserTestProgress) (bool, error) {
+	// Вопросы получаем до изменения состояния, чтобы ошибка не оставила кандидата без следующего вопроса
+	questions, err := h.testService.GetSelectedQuestions(ctx, userTestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get selected questions: %w", err)
+	}
+	index := progress.CurrentQuestionIndex
+	if index < 0 || index >= len(questions) || questions[index].ID != progress.QuestionID {
+		return false, fmt.Errorf("question %d not found in user test %d", progress.QuestionID, userTestID)
+	}
+
+	expired, err := h.testService.ExpireQuestion(ctx, userTestID, index)
+	if err != nil {
+		return false, err
+	}
+	if !expired {
+		// Кандидат успел ответить
+		return false, nil
+	}
+
+	if progress.QuestionMessageID != 0 {
+		message := &telebot.Message{ID: progress.QuestionMessageID, Chat: &telebot.Chat{ID: telegramID}}
+		if err := h.questionSender.Expire(message, questions[index], index+1); err != nil {
+			log.Printf("Failed to mark question %d as expired for user %d: %v", progress.QuestionID, telegramID, err)
+		}
+	}
+
+	next := index + 1
+	if next >= len(questions) {
+		return true, nil
+	}
+
+	err = h.sendQuestion(ctx, &telebot.User{ID: telegramID}, userTestID, questions[next], next)
+	if err != nil {
+		return false, fmt.Errorf("failed to send next question: %w", err)
+	}
+	return false, nil
+}
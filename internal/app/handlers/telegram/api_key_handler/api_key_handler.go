@@ -0,0 +1,80 @@
+package api_key_handler
+
+import (
+	"context"
+	"fmt"
+	authService "github.com/IT-Nick/internal/domain/auth/service"
+	messageService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
+	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"gopkg.in/telebot.v4"
+	"slices"
+)
+
+// apiPermissions права, которые дают доступ хотя бы к одному маршруту HTTP API
+var apiPermissions = []string{
+	model.PermissionViewReports,
+	model.PermissionGenerateQR,
+	model.PermissionAssignHR,
+	model.PermissionAssignTest,
+}
+
+// APIKeyHandler выдает сотрудникам API ключ для HTTP API по команде /apikey
+type APIKeyHandler struct {
+	authService    *authService.AuthService
+	userService    *usersService.UserService
+	messageService *messageService.MessageService
+}
+
+// NewAPIKeyHandler возвращает новый экземпляр обработчика
+func NewAPIKeyHandler(
+	authService *authService.AuthService,
+	userService *usersService.UserService,
+	messageService *messageService.MessageService,
+) *APIKeyHandler {
+	return &APIKeyHandler{
+		authService:    authService,
+		userService:    userService,
+		messageService: messageService,
+	}
+}
+
+// Handle выпускает новый API ключ отправителю. Предыдущие ключи пользователя отзываются.
+func (h *APIKeyHandler) Handle(c telebot.Context) error {
+	ctx := context.Background()
+
+	// Ключ отправляется только в личный чат, чтобы его не увидели участники группы
+	if c.Chat().Type != telebot.ChatPrivate {
+		return c.Send("API ключ можно получить только в личном чате с ботом.")
+	}
+
+	username := c.Sender().Username
+	permissions, err := h.userService.GetPermissionsForUser(ctx, username)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при проверке прав: %v", err))
+	}
+	if !slices.ContainsFunc(permissions, func(p string) bool { return slices.Contains(apiPermissions, p) }) {
+		return c.Send("У вас нет прав на использование API.")
+	}
+
+	key, err := h.authService.IssueAPIKey(ctx, username, "telegram")
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при выпуске API ключа: %v", err))
+	}
+
+	message, err := h.messageService.GetMessageByKey(ctx, "api_key_issued")
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при получении сообщения: %v", err))
+	}
+
+	return c.Send(fmt.Sprintf(message, key), &telebot.SendOptions{
+		ParseMode: telebot.ModeMarkdown,
+	})
+}
+
+// GetHandlerFunc возвращает обработчик в формате telebot.HandlerFunc
+func (h *APIKeyHandler) GetHandlerFunc() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		return h.Handle(c)
+	}
+}
@@ -0,0 +1,234 @@
+package assign_role_handler
+
+import (
+	"context"
+	"fmt"
+	messageService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
+	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"gopkg.in/telebot.v4"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// usernameRx допустимый формат username в Telegram
+var usernameRx = regexp.MustCompile(`^[A-Za-z0-9_]{4,32}$`)
+
+// rolePermissions права, необходимые для назначения роли
+var rolePermissions = map[string]string{
+	model.RoleManager: model.PermissionAssignHR,
+	model.RoleAdmin:   model.PermissionAssignAdmin,
+}
+
+// AssignRoleHandler проводит администратора через назначение роли HR или ADMIN:
+// кнопка -> ввод @username -> подтверждение -> назначение роли и уведомление пользователя
+type AssignRoleHandler struct {
+	bot            *telebot.Bot
+	userService    *usersService.UserService
+	messageService *messageService.MessageService
+	roleState      map[int64]string // telegram ID администратора -> роль, для которой ожидается username
+	mutex          sync.Mutex
+}
+
+// NewAssignRoleHandler возвращает структуру обработчика назначения ролей
+func NewAssignRoleHandler(
+	bot *telebot.Bot,
+	userService *usersService.UserService,
+	messageService *messageService.MessageService,
+	roleState map[int64]string,
+) *AssignRoleHandler {
+	return &AssignRoleHandler{
+		bot:            bot,
+		userService:    userService,
+		messageService: messageService,
+		roleState:      roleState,
+	}
+}
+
+// Handle обрабатывает нажатие кнопки назначения роли и запрашивает username
+func (h *AssignRoleHandler) Handle(c telebot.Context, roleName string) error {
+	ctx := context.Background()
+	if c.Callback() != nil {
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+	}
+
+	allowed, err := h.canAssign(ctx, c.Sender().Username, roleName)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при проверке прав: %v", err))
+	}
+	if !allowed {
+		return c.Send("У вас нет прав на назначение этой роли.")
+	}
+
+	message, err := h.messageService.GetMessageByKey(ctx, "assign_role_enter_username")
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при получении сообщения: %v", err))
+	}
+
+	h.mutex.Lock()
+	h.roleState[c.Sender().ID] = roleName
+	h.mutex.Unlock()
+
+	return c.Send(fmt.Sprintf(message, model.RoleTitle(roleName)), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+}
+
+// AwaitsUsername сообщает, ожидается ли от администратора ввод username
+func (h *AssignRoleHandler) AwaitsUsername(telegramID int64) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, ok := h.roleState[telegramID]
+	return ok
+}
+
+// HandleUsername принимает username и просит подтвердить назначение роли
+func (h *AssignRoleHandler) HandleUsername(c telebot.Context) error {
+	h.mutex.Lock()
+	roleName, ok := h.roleState[c.Sender().ID]
+	h.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	messageText := strings.TrimSpace(c.Message().Text)
+	if !strings.HasPrefix(messageText, "@") {
+		return c.Send("Пожалуйста, укажите имя пользователя в формате @username.")
+	}
+	username := strings.TrimPrefix(messageText, "@")
+	if !usernameRx.MatchString(username) {
+		return c.Send("Некорректное имя пользователя. Укажите его в формате @username.")
+	}
+	if strings.EqualFold(username, c.Sender().Username) {
+		return c.Send("Нельзя изменить роль самому себе.")
+	}
+
+	h.mutex.Lock()
+	delete(h.roleState, c.Sender().ID)
+	h.mutex.Unlock()
+
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("✅ Подтвердить", fmt.Sprintf("role_confirm_%s_%s", roleName, username)),
+		markup.Data("❌ Отмена", "role_cancel"),
+	))
+
+	return c.Send(fmt.Sprintf("Назначить пользователю @%s роль <b>%s</b>?", username, model.RoleTitle(roleName)), &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: markup,
+	})
+}
+
+// HandleCallback обрабатывает подтверждение (role_confirm_<role>_<username>) или отмену (role_cancel) назначения
+func (h *AssignRoleHandler) HandleCallback(c telebot.Context) error {
+	ctx := context.Background()
+
+	cleanedData := strings.TrimSpace(c.Callback().Data)
+	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
+	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
+
+	if cleanedData == "role_cancel" {
+		h.mutex.Lock()
+		delete(h.roleState, c.Sender().ID)
+		h.mutex.Unlock()
+		if err := c.Delete(); err != nil {
+			log.Printf("Failed to delete confirmation message: %v", err)
+		}
+		return c.Respond(&telebot.CallbackResponse{Text: "Назначение роли отменено."})
+	}
+
+	// username может содержать "_", поэтому делим строку не более чем на 4 части
+	parts := strings.SplitN(cleanedData, "_", 4)
+	if len(parts) != 4 || parts[1] != "confirm" {
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+	roleName, username := parts[2], parts[3]
+
+	allowed, err := h.canAssign(ctx, c.Sender().Username, roleName)
+	if err != nil || !allowed {
+		return c.Respond(&telebot.CallbackResponse{Text: "У вас нет прав на назначение этой роли."})
+	}
+
+	// Понижать администраторов может только пользователь с правом assign_admin
+	target, err := h.userService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при поиске пользователя: %v", err)})
+	}
+	if target != nil && roleName != model.RoleAdmin {
+		targetAdmin, err := h.canAssign(ctx, username, model.RoleAdmin)
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при проверке прав: %v", err)})
+		}
+		senderAdmin, err := h.canAssign(ctx, c.Sender().Username, model.RoleAdmin)
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при проверке прав: %v", err)})
+		}
+		if targetAdmin && !senderAdmin {
+			return c.Respond(&telebot.CallbackResponse{Text: "Изменить роль администратора может только администратор."})
+		}
+	}
+
+	user, err := h.userService.PromoteUser(ctx, username, roleName, c.Sender().Username)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при назначении роли: %v", err)})
+	}
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+
+	roleTitle := model.RoleTitle(roleName)
+	if user == nil {
+		return c.Edit(fmt.Sprintf("Пользователь @%s не найден в системе. Роль <b>%s</b> будет назначена, когда он напишет /start.", username, roleTitle), &telebot.SendOptions{
+			ParseMode: telebot.ModeHTML,
+		})
+	}
+
+	// Уведомляем пользователя о новой роли
+	if user.TelegramID != nil {
+		h.notifyPromoted(ctx, *user.TelegramID, roleName)
+	}
+
+	return c.Edit(fmt.Sprintf("Пользователю @%s назначена роль <b>%s</b>.", username, roleTitle), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+}
+
+// notifyPromoted отправляет пользователю уведомление о назначенной роли
+func (h *AssignRoleHandler) notifyPromoted(ctx context.Context, telegramID int64, roleName string) {
+	message, err := h.messageService.GetMessageByKey(ctx, "role_promoted")
+	if err != nil {
+		log.Printf("Failed to get role_promoted message: %v", err)
+		return
+	}
+	_, err = h.bot.Send(&telebot.User{ID: telegramID}, fmt.Sprintf(message, model.RoleTitle(roleName)), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+	if err != nil {
+		log.Printf("Failed to notify promoted user %d: %v", telegramID, err)
+	}
+}
+
+// canAssign проверяет, есть ли у пользователя право назначать роль
+func (h *AssignRoleHandler) canAssign(ctx context.Context, username string, roleName string) (bool, error) {
+	permission, ok := rolePermissions[roleName]
+	if !ok {
+		return false, nil
+	}
+	permissions, err := h.userService.GetPermissionsForUser(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(permissions, permission), nil
+}
+
+// GetHandlerFunc возвращает обработчик кнопки назначения роли roleName в формате telebot.HandlerFunc
+func (h *AssignRoleHandler) GetHandlerFunc(roleName string) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		return h.Handle(c, roleName)
+	}
+}
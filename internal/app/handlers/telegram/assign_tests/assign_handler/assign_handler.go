@@ -3,6 +3,7 @@ package assign_handler
 import (
 	"context"
 	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
 	testsService "github.com/IT-Nick/internal/domain/tests/service"
 	"github.com/IT-Nick/internal/domain/users/service"
 	"gopkg.in/telebot.v4"
@@ -13,14 +14,16 @@ type AssignStartPageHandler struct {
 	userService *service.UserService
 	testService *testsService.TestService
 	pageState   map[int64]int
+	pickerState *model.TestPickerState // Назначение списка выбора теста (model.TestPicker*)
 	mutex       sync.Mutex
 }
 
-func NewAssignStartPageHandler(userService *service.UserService, testService *testsService.TestService, pageState map[int64]int) *AssignStartPageHandler {
+func NewAssignStartPageHandler(userService *service.UserService, testService *testsService.TestService, pageState map[int64]int, pickerState *model.TestPickerState) *AssignStartPageHandler {
 	return &AssignStartPageHandler{
 		userService: userService,
 		testService: testService,
 		pageState:   pageState,
+		pickerState: pickerState,
 	}
 }
 
@@ -32,6 +35,11 @@ func (h *AssignStartPageHandler) Handle(c telebot.Context) error {
 	if page == 0 {
 		page = 1 // Устанавливаем страницу как 1 для первой страницы
 	}
+	// Заголовок списка зависит от того, для чего выбирается тест
+	prompt, ok := model.TestPickerPrompts[h.pickerState.Get(userID)]
+	if !ok {
+		prompt = model.TestPickerPrompts[model.TestPickerAssign]
+	}
 	h.mutex.Unlock() // Освобождаем мьютекс
 
 	pageSize := 3
@@ -75,7 +83,7 @@ func (h *AssignStartPageHandler) Handle(c telebot.Context) error {
 	keyboard = append(keyboard, paginationButtons)
 
 	// Отправляем новое сообщение с клавиатурой
-	return c.Send("Какой тест назначить кандидату?", &telebot.SendOptions{
+	return c.Send(prompt, &telebot.SendOptions{
 		ReplyMarkup: &telebot.ReplyMarkup{
 			InlineKeyboard: keyboard,
 		},
@@ -1,37 +1,82 @@
 package assign_test_handler
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
 	testsService "github.com/IT-Nick/internal/domain/tests/service"
 	"github.com/IT-Nick/internal/domain/users/service"
 	"gopkg.in/telebot.v4"
+	"io"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// maxCSVSize максимальный размер CSV файла со списком кандидатов
+const maxCSVSize = 1 << 20
+
+// maxMessageLength максимальная длина сводки, отправляемой текстом
+const maxMessageLength = 4000
+
+// bulkStatusIcons обозначения результатов массового назначения в сводке
+var bulkStatusIcons = map[string]string{
+	model.BulkAssignAssigned:  "✅",
+	model.BulkAssignPending:   "⏳",
+	model.BulkAssignDuplicate: "🔁",
+	model.BulkAssignInvalid:   "❌",
+}
+
+// DeadlineLayout формат срока начала теста в сообщениях
+const DeadlineLayout = "02.01.2006 15:04"
+
+// deadlineOptions варианты срока начала теста в днях, 0 - без срока
+var deadlineOptions = []struct {
+	Text string
+	Days int
+}{
+	{"1 день", 1},
+	{"3 дня", 3},
+	{"7 дней", 7},
+	{"14 дней", 14},
+	{"Без срока", 0},
+}
+
 // AssignTestHandler обрабатывает назначение теста пользователю
 type AssignTestHandler struct {
 	userService *service.UserService
 	testService *testsService.TestService
-	testState   map[int64]int
+	state       *model.AssignTestState // Тест и срок начала теста, выбранные HR для текущего назначения
+	location    *time.Location         // Часовой пояс срока в сообщениях
 }
 
 // NewAssignTestHandler возвращает структуру обработчика для назначения теста
 func NewAssignTestHandler(
 	userService *service.UserService,
 	testService *testsService.TestService,
-	testState map[int64]int,
+	state *model.AssignTestState,
+	location *time.Location,
 ) *AssignTestHandler {
 	return &AssignTestHandler{
 		userService: userService,
 		testService: testService,
-		testState:   testState,
+		state:       state,
+		location:    location,
 	}
 }
 
+// Handle назначает выбранный тест одному пользователю (@username) или списку пользователей,
+// разделенных переводами строк, запятыми или пробелами
 func (h *AssignTestHandler) Handle(c telebot.Context) error {
 	userID := c.Sender().ID
-	messageText := c.Message().Text
+	messageText := strings.TrimSpace(c.Message().Text)
+
+	if usernames := testsService.ParseUsernameList(messageText); len(usernames) > 1 {
+		return h.assignBulk(c, usernames)
+	}
 
 	if !strings.HasPrefix(messageText, "@") {
 		return c.Send("Пожалуйста, укажите имя пользователя в формате @username.")
@@ -42,7 +87,7 @@ func (h *AssignTestHandler) Handle(c telebot.Context) error {
 		return c.Send("Имя пользователя не может быть пустым.")
 	}
 
-	testID, exists := h.testState[userID]
+	testID, exists := h.state.Test(userID)
 	if !exists {
 		return c.Send("Ошибка: тест не выбран. Пожалуйста, выберите тест заново.")
 	}
@@ -52,6 +97,8 @@ func (h *AssignTestHandler) Handle(c telebot.Context) error {
 		return c.Send("Ошибка: не удалось определить пользователя, назначающего тест. Убедитесь, что у вас установлен username в Telegram.")
 	}
 
+	deadline := h.state.Deadline(userID)
+
 	ctx := context.Background()
 	user, err := h.userService.GetUserByUsername(ctx, username)
 	if err != nil {
@@ -59,25 +106,181 @@ func (h *AssignTestHandler) Handle(c telebot.Context) error {
 	}
 
 	if user == nil {
-		_, err = h.testService.AssignPendingTest(ctx, username, testID, assignedBy)
+		_, err = h.testService.AssignPendingTest(ctx, username, testID, assignedBy, deadline, model.AvailabilityWindow{})
 		if err != nil {
 			return c.Send(fmt.Sprintf("Ошибка при создании отложенного назначения теста: %v", err))
 		}
 
 		// Очищаем состояние теста
-		delete(h.testState, userID)
+		h.state.Delete(userID)
 
-		return c.Send(fmt.Sprintf("Пользователь @%s не найден в системе. Ему был добавлен отложенный тест #%d (когда он напишет /start, он появится в системе уже с назначенным тестом).", username, testID))
+		return c.Send(fmt.Sprintf("Пользователь @%s не найден в системе. Ему был добавлен отложенный тест #%d (когда он напишет /start, он появится в системе уже с назначенным тестом).%s", username, testID, h.deadlineNote(deadline)))
 	}
 
-	_, err = h.testService.AssignTestToUser(ctx, user.ID, testID, assignedBy)
+	_, err = h.testService.AssignTestToUser(ctx, user.ID, testID, assignedBy, deadline, model.AvailabilityWindow{})
 	if err != nil {
 		return c.Send(fmt.Sprintf("Ошибка при назначении теста: %v", err))
 	}
 
-	delete(h.testState, userID)
+	h.state.Delete(userID)
+
+	return c.Send(fmt.Sprintf("Тест #%d успешно назначен пользователю @%s.%s", testID, username, h.deadlineNote(deadline)))
+}
+
+// HandleDocument назначает выбранный тест пользователям из CSV файла (username в первой колонке)
+func (h *AssignTestHandler) HandleDocument(c telebot.Context) error {
+	document := c.Message().Document
+	if document.FileSize > maxCSVSize {
+		return c.Send("Файл слишком большой. Максимальный размер - 1 МБ.")
+	}
+	if !strings.HasSuffix(strings.ToLower(document.FileName), ".csv") && document.MIME != "text/csv" {
+		return c.Send("Пожалуйста, отправьте список кандидатов в формате CSV.")
+	}
+
+	file, err := c.Bot().File(&document.File)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при загрузке файла: %v", err))
+	}
+	defer file.Close()
+
+	usernames, err := testsService.ParseUsernameCSV(io.LimitReader(file, maxCSVSize))
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при чтении CSV: %v", err))
+	}
+	if len(usernames) == 0 {
+		return c.Send("В файле не найдено ни одного username.")
+	}
+	return h.assignBulk(c, usernames)
+}
+
+// AwaitsUsernames сообщает, выбрал ли HR тест и ожидается ли список кандидатов
+func (h *AssignTestHandler) AwaitsUsernames(telegramID int64) bool {
+	_, ok := h.state.Test(telegramID)
+	return ok
+}
+
+// assignBulk назначает выбранный тест списку пользователей и присылает сводку по каждой строке
+func (h *AssignTestHandler) assignBulk(c telebot.Context, usernames []string) error {
+	userID := c.Sender().ID
+	testID, exists := h.state.Test(userID)
+	if !exists {
+		return c.Send("Ошибка: тест не выбран. Пожалуйста, выберите тест заново.")
+	}
+
+	assignedBy := c.Sender().Username
+	if assignedBy == "" {
+		return c.Send("Ошибка: не удалось определить пользователя, назначающего тест. Убедитесь, что у вас установлен username в Telegram.")
+	}
+
+	deadline := h.state.Deadline(userID)
+	results, err := h.testService.BulkAssignTest(context.Background(), testID, usernames, assignedBy, deadline, model.AvailabilityWindow{})
+	if errors.Is(err, testsService.ErrTooManyUsernames) {
+		return c.Send(fmt.Sprintf("Слишком много кандидатов. За один раз можно назначить тест не более чем %d пользователям.", testsService.MaxBulkAssignments))
+	}
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при назначении теста: %v. Ни одно назначение не сохранено.", err))
+	}
+
+	h.state.Delete(userID)
+
+	counts := testsService.CountBulkResults(results)
+	summary := fmt.Sprintf("Тест #%d: назначено - %d, отложено - %d, повторы - %d, ошибки - %d.%s",
+		testID,
+		counts[model.BulkAssignAssigned],
+		counts[model.BulkAssignPending],
+		counts[model.BulkAssignDuplicate],
+		counts[model.BulkAssignInvalid],
+		h.deadlineNote(deadline),
+	)
+
+	var lines strings.Builder
+	for _, result := range results {
+		username := "@" + result.Username
+		if result.Status == model.BulkAssignInvalid {
+			username = result.Username
+		}
+		fmt.Fprintf(&lines, "\n%s %s", bulkStatusIcons[result.Status], username)
+	}
+
+	// Длинная сводка не помещается в сообщение, поэтому построчные результаты отправляются CSV файлом
+	if len(summary)+lines.Len() <= maxMessageLength {
+		return c.Send(summary + "\n" + lines.String())
+	}
+
+	var report bytes.Buffer
+	writer := csv.NewWriter(&report)
+	_ = writer.Write([]string{"username", "status", "user_test_id"})
+	for _, result := range results {
+		_ = writer.Write([]string{result.Username, result.Status, strconv.Itoa(result.UserTestID)})
+	}
+	writer.Flush()
+
+	return c.Send(&telebot.Document{
+		File:     telebot.FromReader(&report),
+		FileName: fmt.Sprintf("assignments_test_%d.csv", testID),
+		Caption:  summary,
+	})
+}
+
+// HandleDeadline обрабатывает выбор срока начала теста (callback deadline_<дни>, 0 - без срока)
+func (h *AssignTestHandler) HandleDeadline(c telebot.Context) error {
+	cleanedData := strings.TrimSpace(c.Callback().Data)
+	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
+	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
+
+	days, err := strconv.Atoi(strings.TrimPrefix(cleanedData, "deadline_"))
+	if err != nil || days < 0 {
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+
+	userID := c.Sender().ID
+	testID, exists := h.state.Test(userID)
+	if !exists {
+		return c.Respond(&telebot.CallbackResponse{Text: "Тест не выбран. Пожалуйста, выберите тест заново."})
+	}
+
+	text := "Срок не ограничен."
+	if days == 0 {
+		h.state.SetDeadline(userID, nil)
+	} else {
+		deadline := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+		h.state.SetDeadline(userID, &deadline)
+		text = fmt.Sprintf("Тест нужно начать до %s.", deadline.In(h.location).Format(DeadlineLayout))
+	}
 
-	return c.Send(fmt.Sprintf("Тест #%d успешно назначен пользователю @%s.", testID, username))
+	if err := c.Respond(&telebot.CallbackResponse{Text: text}); err != nil {
+		return err
+	}
+	return c.Edit(SelectedTestPrompt(testID)+"\n\n"+text, &telebot.SendOptions{
+		ReplyMarkup: DeadlineMarkup(),
+	})
+}
+
+// SelectedTestPrompt приглашение ввести кандидатов после выбора теста.
+// Окно доступности в Telegram не задается, назначения с окном создаются только через POST /assignments/bulk.
+func SelectedTestPrompt(testID int) string {
+	return fmt.Sprintf("Тест #%d выбран. Введите имя кандидата (например, @username), список имен через запятую или с новой строки "+
+		"либо отправьте CSV файл с username в первой колонке. Кнопками ниже можно задать срок, до которого кандидат должен начать тест. "+
+		"Окно доступности (дата и время, когда можно начать тест) задается только через API.", testID)
+}
+
+// DeadlineMarkup клавиатура выбора срока, до которого кандидат должен начать тест
+func DeadlineMarkup() *telebot.ReplyMarkup {
+	markup := &telebot.ReplyMarkup{}
+	var buttons []telebot.Btn
+	for _, option := range deadlineOptions {
+		buttons = append(buttons, markup.Data(option.Text, fmt.Sprintf("deadline_%d", option.Days)))
+	}
+	markup.Inline(markup.Row(buttons...))
+	return markup
+}
+
+// deadlineNote описание срока для сообщения об успешном назначении
+func (h *AssignTestHandler) deadlineNote(deadline *time.Time) string {
+	if deadline == nil {
+		return ""
+	}
+	return fmt.Sprintf(" Тест нужно начать до %s.", deadline.In(h.location).Format(DeadlineLayout))
 }
 
 func (h *AssignTestHandler) GetHandlerFunc() telebot.HandlerFunc {
@@ -1,7 +1,8 @@
 package select_test_handler
 
 import (
-	"fmt"
+	"github.com/IT-Nick/internal/app/handlers/telegram/assign_tests/assign_test_handler"
+	"github.com/IT-Nick/internal/domain/model"
 	testsService "github.com/IT-Nick/internal/domain/tests/service"
 	usersService "github.com/IT-Nick/internal/domain/users/service"
 	tgbotapi "gopkg.in/telebot.v4"
@@ -12,18 +13,18 @@ import (
 type SelectTestHandler struct {
 	userService *usersService.UserService
 	testService *testsService.TestService
-	testState   map[int64]int
+	state       *model.AssignTestState
 }
 
 func NewSelectTestHandler(
 	userService *usersService.UserService,
 	testService *testsService.TestService,
-	testState map[int64]int,
+	state *model.AssignTestState,
 ) *SelectTestHandler {
 	return &SelectTestHandler{
 		userService: userService,
 		testService: testService,
-		testState:   testState,
+		state:       state,
 	}
 }
 
@@ -43,10 +44,12 @@ func (h *SelectTestHandler) Handle(c tgbotapi.Context) error {
 
 	// Сохраняем выбранный тест в состояние
 	userID := c.Sender().ID
-	h.testState[userID] = testID // Сохраняем testID для данного пользователя
+	h.state.Select(userID, testID) // Сохраняем testID для данного пользователя, срок предыдущего назначения сбрасывается
 
 	// Дополнительные действия (например, запрос кандидата)
-	return c.Send(fmt.Sprintf("Тест #%d выбран. Введите имя кандидата (например, @username).", testID))
+	return c.Send(assign_test_handler.SelectedTestPrompt(testID), &tgbotapi.SendOptions{
+		ReplyMarkup: assign_test_handler.DeadlineMarkup(),
+	})
 }
 
 func (h *SelectTestHandler) GetHandlerFunc() tgbotapi.HandlerFunc {
@@ -0,0 +1,510 @@
+package assignments_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	messageService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"gopkg.in/telebot.v4"
+	"html"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pageSize количество назначений и тестов на одной странице
+const pageSize = 5
+
+// dateLayout формат дат в карточке назначения
+const dateLayout = "02.01.2006 15:04"
+
+// usernamePattern допустимый username в Telegram
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{4,32}$`)
+
+// statusTitles названия статусов назначения
+var statusTitles = map[string]string{
+	model.UserTestStatusPending:       "⏳ ожидает /start",
+	model.UserTestStatusAssigned:      "📨 назначен",
+	model.UserTestStatusInProgress:    "✍️ проходит",
+	model.UserTestStatusPendingReview: "🔎 на проверке",
+	model.UserTestStatusFinished:      "✅ завершен",
+	model.UserTestStatusExpired:       "⌛ просрочен",
+	model.UserTestStatusCancelled:     "❌ отменен",
+}
+
+// AssignmentsHandler показывает HR его назначения (/assignments) и выполняет действия с ними:
+// отмена, замена теста, передача другому HR и повторная отправка приглашения
+type AssignmentsHandler struct {
+	bot            *telebot.Bot
+	userService    *usersService.UserService
+	testService    *testsService.TestService
+	messageService *messageService.MessageService
+	transferState  map[int64]int  // Назначение, для которого HR вводит username нового владельца
+	location       *time.Location // Часовой пояс дат в карточке назначения
+	mutex          sync.Mutex
+}
+
+// NewAssignmentsHandler возвращает новый экземпляр обработчика
+func NewAssignmentsHandler(
+	bot *telebot.Bot,
+	userService *usersService.UserService,
+	testService *testsService.TestService,
+	messageService *messageService.MessageService,
+	location *time.Location,
+) *AssignmentsHandler {
+	return &AssignmentsHandler{
+		bot:            bot,
+		userService:    userService,
+		testService:    testService,
+		messageService: messageService,
+		transferState:  make(map[int64]int),
+		location:       location,
+	}
+}
+
+// Handle обрабатывает команду /assignments: показывает первую страницу назначений
+func (h *AssignmentsHandler) Handle(c telebot.Context) error {
+	hr, err := h.currentHR(context.Background(), c)
+	if err != nil {
+		return c.Send(err.Error())
+	}
+
+	h.mutex.Lock()
+	delete(h.transferState, c.Sender().ID)
+	h.mutex.Unlock()
+
+	text, markup, err := h.listPage(context.Background(), hr.ID, 1)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при получении назначений: %v", err))
+	}
+	return c.Send(text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: markup,
+	})
+}
+
+// HandleCallback обрабатывает кнопки списка назначений (callback asg_<действие>_<id>[_<id теста>])
+func (h *AssignmentsHandler) HandleCallback(c telebot.Context) error {
+	cleanedData := strings.TrimSpace(c.Callback().Data)
+	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
+	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
+
+	parts := strings.Split(cleanedData, "_")
+	if len(parts) < 3 {
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+	var values []int
+	for _, part := range parts[2:] {
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("invalid callback data: %s", cleanedData)
+		}
+		values = append(values, value)
+	}
+
+	ctx := context.Background()
+	hr, err := h.currentHR(ctx, c)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: err.Error(), ShowAlert: true})
+	}
+
+	// Выбор нового теста (asg_to_<id>_<id теста>) и страница списка тестов (asg_tests_<id>_<страница>) передают два числа
+	action := parts[1]
+	switch {
+	case len(values) == 2 && action == "to":
+		return h.reassign(c, hr, values[0], values[1])
+	case len(values) == 2 && action == "tests":
+		return h.showTests(c, hr, values[0], values[1])
+	case len(values) != 1:
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+
+	switch action {
+	case "page":
+		text, markup, err := h.listPage(ctx, hr.ID, values[0])
+		if err != nil {
+			return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при получении назначений: %v", err)})
+		}
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+		return c.Edit(text, &telebot.SendOptions{ParseMode: telebot.ModeHTML, ReplyMarkup: markup})
+	case "view":
+		return h.showAssignment(c, hr, values[0], "")
+	case "cancel":
+		return h.confirmCancel(c, hr, values[0])
+	case "cancelok":
+		return h.cancel(c, hr, values[0])
+	case "reassign":
+		return h.showTests(c, hr, values[0], 1)
+	case "transfer":
+		return h.askTransfer(c, hr, values[0])
+	case "resend":
+		return h.resend(c, hr, values[0])
+	default:
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+}
+
+// AwaitsUsername сообщает, ожидается ли от HR username нового владельца назначения
+func (h *AssignmentsHandler) AwaitsUsername(telegramID int64) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	_, ok := h.transferState[telegramID]
+	return ok
+}
+
+// HandleUsername передает назначение HR, username которого прислал текущий владелец
+func (h *AssignmentsHandler) HandleUsername(c telebot.Context) error {
+	h.mutex.Lock()
+	userTestID, ok := h.transferState[c.Sender().ID]
+	h.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+
+	username := strings.TrimPrefix(strings.TrimSpace(c.Message().Text), "@")
+	if !usernamePattern.MatchString(username) {
+		return c.Send("Пожалуйста, укажите имя HR в формате @username.")
+	}
+
+	h.mutex.Lock()
+	delete(h.transferState, c.Sender().ID)
+	h.mutex.Unlock()
+
+	ctx := context.Background()
+	hr, err := h.currentHR(ctx, c)
+	if err != nil {
+		return c.Send(err.Error())
+	}
+	if strings.EqualFold(username, hr.TelegramUsername) {
+		return c.Send("Назначение уже принадлежит вам.")
+	}
+
+	newHR, err := h.userService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при поиске пользователя @%s: %v", username, err))
+	}
+	if newHR == nil {
+		return c.Send(fmt.Sprintf("Пользователь @%s не найден. Он должен хотя бы раз написать боту /start.", username))
+	}
+	permissions, err := h.userService.GetPermissionsForUser(ctx, newHR.TelegramUsername)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при проверке прав: %v", err))
+	}
+	if !slices.Contains(permissions, model.PermissionAssignTest) {
+		return c.Send(fmt.Sprintf("У пользователя @%s нет прав на назначение тестов.", username))
+	}
+
+	assignment, err := h.testService.TransferAssignment(ctx, userTestID, hr.ID, newHR.ID)
+	if errors.Is(err, testsService.ErrAssignmentNotFound) {
+		return c.Send("Назначение не найдено или уже отменено.")
+	}
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при передаче назначения: %v", err))
+	}
+
+	message, err := h.messageService.GetMessageByKey(ctx, "assignment_transferred")
+	if err != nil {
+		log.Printf("Failed to get assignment_transferred message: %v", err)
+	} else if newHR.TelegramID != nil {
+		text := fmt.Sprintf(message,
+			html.EscapeString(hr.TelegramUsername),
+			html.EscapeString(assignment.TestName),
+			html.EscapeString(assignment.CandidateUsername),
+		)
+		if _, err := h.bot.Send(&telebot.User{ID: *newHR.TelegramID}, text, &telebot.SendOptions{ParseMode: telebot.ModeHTML}); err != nil {
+			log.Printf("Failed to notify HR %s about transferred assignment: %v", newHR.TelegramUsername, err)
+		}
+	}
+
+	return c.Send(fmt.Sprintf("Назначение теста «%s» кандидату @%s передано @%s.", assignment.TestName, assignment.CandidateUsername, username))
+}
+
+// listPage формирует страницу списка назначений HR
+func (h *AssignmentsHandler) listPage(ctx context.Context, hrID int, page int) (string, *telebot.ReplyMarkup, error) {
+	total, err := h.testService.CountAssignmentsByHR(ctx, hrID)
+	if err != nil {
+		return "", nil, err
+	}
+	markup := &telebot.ReplyMarkup{}
+	if total == 0 {
+		return "У вас пока нет назначений.", markup, nil
+	}
+
+	totalPages := (total + pageSize - 1) / pageSize
+	page = max(1, min(page, totalPages))
+
+	assignments, err := h.testService.GetAssignmentsByHR(ctx, hrID, page, pageSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var rows []telebot.Row
+	for _, assignment := range assignments {
+		text := fmt.Sprintf("@%s · %s · %s", assignment.CandidateUsername, assignment.TestName, statusTitles[assignment.Status])
+		rows = append(rows, markup.Row(markup.Data(text, fmt.Sprintf("asg_view_%d", assignment.UserTestID))))
+	}
+	var navigation []telebot.Btn
+	if page > 1 {
+		navigation = append(navigation, markup.Data("⬅️ Назад", fmt.Sprintf("asg_page_%d", page-1)))
+	}
+	if page < totalPages {
+		navigation = append(navigation, markup.Data("Вперед ➡️", fmt.Sprintf("asg_page_%d", page+1)))
+	}
+	if len(navigation) > 0 {
+		rows = append(rows, markup.Row(navigation...))
+	}
+	markup.Inline(rows...)
+
+	return fmt.Sprintf("<b>Ваши назначения</b> (страница %d из %d, всего %d). Выберите назначение:", page, totalPages, total), markup, nil
+}
+
+// showAssignment показывает карточку назначения с доступными действиями
+func (h *AssignmentsHandler) showAssignment(c telebot.Context, hr *model.User, userTestID int, notice string) error {
+	assignment, err := h.testService.GetAssignmentForHR(context.Background(), userTestID, hr.ID)
+	if errors.Is(err, testsService.ErrAssignmentNotFound) {
+		return c.Respond(&telebot.CallbackResponse{Text: "Назначение не найдено или уже отменено."})
+	}
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при получении назначения: %v", err)})
+	}
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+
+	var text strings.Builder
+	if notice != "" {
+		text.WriteString(notice + "\n\n")
+	}
+	fmt.Fprintf(&text, "<b>Назначение #%d</b>\nКандидат: @%s\nТест: %s\nСтатус: %s\nНазначен: %s",
+		assignment.UserTestID,
+		html.EscapeString(assignment.CandidateUsername),
+		html.EscapeString(assignment.TestName),
+		statusTitles[assignment.Status],
+		assignment.CreatedAt.In(h.location).Format(dateLayout),
+	)
+	if assignment.Deadline != nil {
+		fmt.Fprintf(&text, "\nНачать до: %s", assignment.Deadline.In(h.location).Format(dateLayout))
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	if notStarted(assignment) {
+		rows = append(rows,
+			markup.Row(
+				markup.Data("❌ Отменить", fmt.Sprintf("asg_cancel_%d", assignment.UserTestID)),
+				markup.Data("🔄 Другой тест", fmt.Sprintf("asg_reassign_%d", assignment.UserTestID)),
+			),
+		)
+	}
+	actions := []telebot.Btn{markup.Data("👤 Передать HR", fmt.Sprintf("asg_transfer_%d", assignment.UserTestID))}
+	if assignment.Status == model.UserTestStatusAssigned && assignment.CandidateTelegramID != nil {
+		actions = append(actions, markup.Data("📨 Отправить приглашение", fmt.Sprintf("asg_resend_%d", assignment.UserTestID)))
+	}
+	rows = append(rows, markup.Row(actions...))
+	rows = append(rows, markup.Row(markup.Data("⬅️ К списку", "asg_page_1")))
+	markup.Inline(rows...)
+
+	return c.Edit(text.String(), &telebot.SendOptions{ParseMode: telebot.ModeHTML, ReplyMarkup: markup})
+}
+
+// confirmCancel спрашивает подтверждение отмены назначения
+func (h *AssignmentsHandler) confirmCancel(c telebot.Context, hr *model.User, userTestID int) error {
+	assignment, err := h.testService.GetAssignmentForHR(context.Background(), userTestID, hr.ID)
+	if err != nil || !notStarted(assignment) {
+		return c.Respond(&telebot.CallbackResponse{Text: "Отменить можно только не начатое назначение."})
+	}
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(
+		markup.Data("Да, отменить", fmt.Sprintf("asg_cancelok_%d", userTestID)),
+		markup.Data("Нет", fmt.Sprintf("asg_view_%d", userTestID)),
+	))
+	return c.Edit(fmt.Sprintf("Отменить назначение теста <b>%s</b> кандидату @%s? Кандидат получит уведомление.",
+		html.EscapeString(assignment.TestName), html.EscapeString(assignment.CandidateUsername)),
+		&telebot.SendOptions{ParseMode: telebot.ModeHTML, ReplyMarkup: markup})
+}
+
+// cancel отменяет назначение и уведомляет кандидата
+func (h *AssignmentsHandler) cancel(c telebot.Context, hr *model.User, userTestID int) error {
+	ctx := context.Background()
+	assignment, err := h.testService.CancelAssignment(ctx, userTestID, hr.ID)
+	if errors.Is(err, testsService.ErrAssignmentNotFound) {
+		return c.Respond(&telebot.CallbackResponse{Text: "Отменить можно только не начатое назначение."})
+	}
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при отмене назначения: %v", err)})
+	}
+
+	if assignment.CandidateTelegramID != nil {
+		message, err := h.messageService.GetMessageByKey(ctx, "assignment_cancelled")
+		if err != nil {
+			log.Printf("Failed to get assignment_cancelled message: %v", err)
+		} else {
+			text := fmt.Sprintf(message, html.EscapeString(assignment.TestName))
+			if _, err := h.bot.Send(&telebot.User{ID: *assignment.CandidateTelegramID}, text, &telebot.SendOptions{ParseMode: telebot.ModeHTML}); err != nil {
+				log.Printf("Failed to notify candidate %s about cancelled assignment: %v", assignment.CandidateUsername, err)
+			}
+		}
+	}
+
+	if err := c.Respond(&telebot.CallbackResponse{Text: "Назначение отменено."}); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+	text, markup, err := h.listPage(ctx, hr.ID, 1)
+	if err != nil {
+		return c.Edit("Назначение отменено.")
+	}
+	return c.Edit(text, &telebot.SendOptions{ParseMode: telebot.ModeHTML, ReplyMarkup: markup})
+}
+
+// showTests показывает тесты, на которые можно заменить тест назначения
+func (h *AssignmentsHandler) showTests(c telebot.Context, hr *model.User, userTestID int, page int) error {
+	ctx := context.Background()
+	assignment, err := h.testService.GetAssignmentForHR(ctx, userTestID, hr.ID)
+	if err != nil || !notStarted(assignment) {
+		return c.Respond(&telebot.CallbackResponse{Text: "Заменить тест можно только в не начатом назначении."})
+	}
+
+	total, err := h.testService.GetTotalTestsCount(ctx)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при получении тестов: %v", err)})
+	}
+	totalPages := max(1, (total+pageSize-1)/pageSize)
+	page = max(1, min(page, totalPages))
+
+	tests, err := h.testService.GetTestsWithPagination(ctx, page, pageSize)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при получении тестов: %v", err)})
+	}
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, test := range tests {
+		if test.ID == assignment.TestID {
+			continue
+		}
+		rows = append(rows, markup.Row(markup.Data(test.TestName, fmt.Sprintf("asg_to_%d_%d", userTestID, test.ID))))
+	}
+	var navigation []telebot.Btn
+	if page > 1 {
+		navigation = append(navigation, markup.Data("⬅️ Назад", fmt.Sprintf("asg_tests_%d_%d", userTestID, page-1)))
+	}
+	if page < totalPages {
+		navigation = append(navigation, markup.Data("Вперед ➡️", fmt.Sprintf("asg_tests_%d_%d", userTestID, page+1)))
+	}
+	if len(navigation) > 0 {
+		rows = append(rows, markup.Row(navigation...))
+	}
+	rows = append(rows, markup.Row(markup.Data("Отмена", fmt.Sprintf("asg_view_%d", userTestID))))
+	markup.Inline(rows...)
+
+	return c.Edit(fmt.Sprintf("Текущий тест: <b>%s</b>. Выберите новый тест для @%s:",
+		html.EscapeString(assignment.TestName), html.EscapeString(assignment.CandidateUsername)),
+		&telebot.SendOptions{ParseMode: telebot.ModeHTML, ReplyMarkup: markup})
+}
+
+// reassign заменяет тест в назначении и показывает обновленную карточку
+func (h *AssignmentsHandler) reassign(c telebot.Context, hr *model.User, userTestID int, testID int) error {
+	assignment, err := h.testService.ReassignTest(context.Background(), userTestID, hr.ID, testID)
+	if errors.Is(err, testsService.ErrAssignmentNotFound) {
+		return c.Respond(&telebot.CallbackResponse{Text: "Заменить тест можно только в не начатом назначении."})
+	}
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при замене теста: %v", err)})
+	}
+	notice := fmt.Sprintf("Тест заменен на «%s».", html.EscapeString(assignment.TestName))
+	if assignment.CandidateTelegramID != nil {
+		notice += " Кандидату можно отправить новое приглашение."
+	}
+	return h.showAssignment(c, hr, userTestID, notice)
+}
+
+// askTransfer запрашивает username HR, которому передается назначение
+func (h *AssignmentsHandler) askTransfer(c telebot.Context, hr *model.User, userTestID int) error {
+	if _, err := h.testService.GetAssignmentForHR(context.Background(), userTestID, hr.ID); err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Назначение не найдено или уже отменено."})
+	}
+
+	h.mutex.Lock()
+	h.transferState[c.Sender().ID] = userTestID
+	h.mutex.Unlock()
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+	return c.Send("Введите имя HR, которому нужно передать назначение (например, @username).")
+}
+
+// resend повторно отправляет кандидату приглашение пройти тест
+func (h *AssignmentsHandler) resend(c telebot.Context, hr *model.User, userTestID int) error {
+	ctx := context.Background()
+	assignment, err := h.testService.GetAssignmentForHR(ctx, userTestID, hr.ID)
+	if err != nil || assignment.Status != model.UserTestStatusAssigned || assignment.CandidateTelegramID == nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Приглашение можно отправить только кандидату, который писал боту и еще не начал тест."})
+	}
+
+	message, err := h.messageService.GetMessageByKey(ctx, "assignment_invitation")
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при получении сообщения: %v", err)})
+	}
+	buttons, err := h.messageService.GetButtons(ctx)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при получении кнопок: %v", err)})
+	}
+	markup := &telebot.ReplyMarkup{}
+	markup.Inline(markup.Row(markup.Data(buttons[model.StartTestKey], model.StartTestKey, strconv.Itoa(assignment.UserTestID))))
+
+	text := fmt.Sprintf(message, html.EscapeString(assignment.TestName), assignment.Duration, assignment.QuestionCount)
+	_, err = h.bot.Send(&telebot.User{ID: *assignment.CandidateTelegramID}, text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Не удалось отправить приглашение: %v", err), ShowAlert: true})
+	}
+	return c.Respond(&telebot.CallbackResponse{Text: "Приглашение отправлено."})
+}
+
+// currentHR возвращает отправителя, если у него есть право назначать тесты
+func (h *AssignmentsHandler) currentHR(ctx context.Context, c telebot.Context) (*model.User, error) {
+	username := c.Sender().Username
+	if username == "" {
+		return nil, errors.New("Не удалось определить пользователя. Убедитесь, что у вас установлен username в Telegram.")
+	}
+	permissions, err := h.userService.GetPermissionsForUser(ctx, username)
+	if err != nil || !slices.Contains(permissions, model.PermissionAssignTest) {
+		return nil, errors.New("У вас нет прав на управление назначениями.")
+	}
+	user, err := h.userService.GetUserByUsername(ctx, username)
+	if err != nil || user == nil {
+		return nil, errors.New("Пользователь не найден. Напишите /start.")
+	}
+	return user, nil
+}
+
+// notStarted сообщает, что кандидат еще не начал тест и назначение можно отменить или изменить
+func notStarted(assignment *model.Assignment) bool {
+	return assignment.Status == model.UserTestStatusPending || assignment.Status == model.UserTestStatusAssigned
+}
+
+// GetHandlerFunc возвращает обработчик в формате telebot.HandlerFunc
+func (h *AssignmentsHandler) GetHandlerFunc() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		return h.Handle(c)
+	}
+}
@@ -0,0 +1,294 @@
+package qr_handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	messageService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"github.com/IT-Nick/internal/infra/qr"
+	"github.com/google/uuid"
+	"gopkg.in/telebot.v4"
+	"html"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expiryOptions варианты срока действия ссылки в часах, 0 - бессрочно
+var expiryOptions = []struct {
+	Text  string
+	Hours int
+}{
+	{"1 день", 24},
+	{"7 дней", 24 * 7},
+	{"30 дней", 24 * 30},
+	{"Бессрочно", 0},
+}
+
+// usageOptions варианты лимита использований ссылки, 0 - без лимита
+var usageOptions = []struct {
+	Text    string
+	MaxUses int
+}{
+	{"1", 1},
+	{"10", 10},
+	{"50", 50},
+	{"Без лимита", 0},
+}
+
+// qrDraft параметры QR-кода, которые HR выбирает по шагам
+type qrDraft struct {
+	testID      int
+	expiryHours int
+}
+
+// QRHandler проводит HR через генерацию QR-кода в чате:
+// /qr -> выбор теста в общем списке с пагинацией -> срок действия -> лимит использований -> фото с QR-кодом и ссылкой
+type QRHandler struct {
+	userService    *usersService.UserService
+	testService    *testsService.TestService
+	messageService *messageService.MessageService
+	renderer       *qr.Renderer
+	botUsername    string
+	showTests      telebot.HandlerFunc    // Показывает первую страницу списка тестов
+	pickerState    *model.TestPickerState // Назначение списка выбора теста (model.TestPicker*)
+	drafts         map[int64]*qrDraft
+	location       *time.Location // Часовой пояс срока действия ссылки в сообщениях
+	mutex          sync.Mutex
+}
+
+// NewQRHandler возвращает новый экземпляр обработчика
+func NewQRHandler(
+	userService *usersService.UserService,
+	testService *testsService.TestService,
+	messageService *messageService.MessageService,
+	renderer *qr.Renderer,
+	botUsername string,
+	showTests telebot.HandlerFunc,
+	pickerState *model.TestPickerState,
+	location *time.Location,
+) *QRHandler {
+	return &QRHandler{
+		userService:    userService,
+		testService:    testService,
+		messageService: messageService,
+		renderer:       renderer,
+		botUsername:    botUsername,
+		showTests:      showTests,
+		pickerState:    pickerState,
+		drafts:         make(map[int64]*qrDraft),
+		location:       location,
+	}
+}
+
+// Handle обрабатывает команду /qr и кнопку генерации QR-кода: показывает список тестов
+func (h *QRHandler) Handle(c telebot.Context) error {
+	if c.Callback() != nil {
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+	}
+
+	allowed, err := h.canGenerate(context.Background(), c.Sender().Username)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при проверке прав: %v", err))
+	}
+	if !allowed {
+		return c.Send("У вас нет прав на генерацию QR-кодов.")
+	}
+
+	h.mutex.Lock()
+	h.pickerState.Set(c.Sender().ID, model.TestPickerQR)
+	delete(h.drafts, c.Sender().ID)
+	h.mutex.Unlock()
+
+	return h.showTests(c)
+}
+
+// AwaitsTest сообщает, выбирает ли пользователь тест для QR-кода
+func (h *QRHandler) AwaitsTest(telegramID int64) bool {
+	return h.pickerState.Get(telegramID) == model.TestPickerQR
+}
+
+// HandleTestSelected обрабатывает выбор теста (callback test_<id>) и спрашивает срок действия ссылки
+func (h *QRHandler) HandleTestSelected(c telebot.Context) error {
+	cleanedData := strings.TrimSpace(c.Callback().Data)
+	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
+	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
+
+	testID, err := strconv.Atoi(strings.TrimPrefix(cleanedData, "test_"))
+	if err != nil {
+		return c.Send("Ошибка при выборе теста.")
+	}
+
+	test, err := h.testService.GetTestByID(context.Background(), testID)
+	if err != nil || test == nil {
+		return c.Send("Тест не найден.")
+	}
+
+	h.mutex.Lock()
+	h.pickerState.Delete(c.Sender().ID)
+	h.drafts[c.Sender().ID] = &qrDraft{testID: testID}
+	h.mutex.Unlock()
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+
+	markup := &telebot.ReplyMarkup{}
+	var buttons []telebot.Btn
+	for _, option := range expiryOptions {
+		buttons = append(buttons, markup.Data(option.Text, fmt.Sprintf("qr_exp_%d", option.Hours)))
+	}
+	markup.Inline(markup.Row(buttons...))
+
+	// Окно доступности в Telegram не задается, ссылку с окном можно выпустить только через POST /tests/generate-link
+	text := fmt.Sprintf("Тест <b>%s</b>. Сколько будет действовать ссылка?\n\n"+
+		"<i>Окно доступности (дата и время, когда можно начать тест) задается только через API.</i>", html.EscapeString(test.TestName))
+	return c.Edit(text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: markup,
+	})
+}
+
+// HandleCallback обрабатывает выбор срока действия (qr_exp_<часы>) и лимита использований (qr_max_<n>)
+func (h *QRHandler) HandleCallback(c telebot.Context) error {
+	cleanedData := strings.TrimSpace(c.Callback().Data)
+	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
+	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
+
+	parts := strings.Split(cleanedData, "_")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+	value, err := strconv.Atoi(parts[2])
+	if err != nil || value < 0 {
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+
+	h.mutex.Lock()
+	draft, ok := h.drafts[c.Sender().ID]
+	h.mutex.Unlock()
+	if !ok {
+		return c.Respond(&telebot.CallbackResponse{Text: "Начните заново командой /qr."})
+	}
+
+	switch parts[1] {
+	case "exp":
+		h.mutex.Lock()
+		draft.expiryHours = value
+		h.mutex.Unlock()
+
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+
+		markup := &telebot.ReplyMarkup{}
+		var buttons []telebot.Btn
+		for _, option := range usageOptions {
+			buttons = append(buttons, markup.Data(option.Text, fmt.Sprintf("qr_max_%d", option.MaxUses)))
+		}
+		markup.Inline(markup.Row(buttons...))
+
+		return c.Edit("Сколько кандидатов смогут получить тест по ссылке?", &telebot.SendOptions{
+			ReplyMarkup: markup,
+		})
+	case "max":
+		h.mutex.Lock()
+		delete(h.drafts, c.Sender().ID)
+		h.mutex.Unlock()
+
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+		if err := c.Delete(); err != nil {
+			log.Printf("Failed to delete message: %v", err)
+		}
+		return h.sendQRCode(c, draft.testID, draft.expiryHours, value)
+	default:
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+}
+
+// sendQRCode выпускает ссылку на тест от имени отправителя и присылает QR-код фотографией
+func (h *QRHandler) sendQRCode(c telebot.Context, testID int, expiryHours int, maxUses int) error {
+	ctx := context.Background()
+
+	// Права проверяются повторно: роль могли изменить, пока HR выбирал параметры
+	allowed, err := h.canGenerate(ctx, c.Sender().Username)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при проверке прав: %v", err))
+	}
+	if !allowed {
+		return c.Send("У вас нет прав на генерацию QR-кодов.")
+	}
+
+	user, err := h.userService.GetUserByUsername(ctx, c.Sender().Username)
+	if err != nil || user == nil {
+		return c.Send("Пользователь не найден. Напишите /start.")
+	}
+	test, err := h.testService.GetTestByID(ctx, testID)
+	if err != nil || test == nil {
+		return c.Send("Тест не найден.")
+	}
+
+	var expiresAt *time.Time
+	expiryText := "бессрочно"
+	if expiryHours > 0 {
+		expires := time.Now().Add(time.Duration(expiryHours) * time.Hour)
+		expiresAt = &expires
+		expiryText = "до " + expires.In(h.location).Format("02.01.2006 15:04")
+	}
+	var maxUsesLimit *int
+	usageText := "без лимита"
+	if maxUses > 0 {
+		maxUsesLimit = &maxUses
+		usageText = strconv.Itoa(maxUses)
+	}
+
+	token := uuid.New().String()
+	if _, err := h.testService.SaveTestLink(ctx, testID, token, user.ID, expiresAt, maxUsesLimit, model.AvailabilityWindow{}); err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при сохранении ссылки: %v", err))
+	}
+	link := testsService.TestDeepLink(h.botUsername, testID, token)
+
+	image, err := h.renderer.PNG(link, qr.Options{})
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при генерации QR-кода: %v", err))
+	}
+
+	message, err := h.messageService.GetMessageByKey(ctx, "qr_link_created")
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при получении сообщения: %v", err))
+	}
+
+	photo := &telebot.Photo{
+		File:    telebot.FromReader(bytes.NewReader(image)),
+		Caption: fmt.Sprintf(message, html.EscapeString(test.TestName), link, expiryText, usageText),
+	}
+	return c.Send(photo, &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+}
+
+// canGenerate проверяет право пользователя на генерацию QR-кодов
+func (h *QRHandler) canGenerate(ctx context.Context, username string) (bool, error) {
+	permissions, err := h.userService.GetPermissionsForUser(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(permissions, model.PermissionGenerateQR), nil
+}
+
+// GetHandlerFunc возвращает обработчик в формате telebot.HandlerFunc
+func (h *QRHandler) GetHandlerFunc() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		return h.Handle(c)
+	}
+}
@@ -0,0 +1,112 @@
+package question_sender
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+	"strings"
+)
+
+// Действия кнопок ответа на вопрос, они же префиксы callback данных
+const (
+	ActionAnswer  = "answer"  // Выбор варианта в вопросе с одним ответом
+	ActionToggle  = "toggle"  // Отметка варианта в вопросе с несколькими ответами
+	ActionConfirm = "confirm" // Подтверждение отмеченных вариантов
+)
+
+const (
+	nonceSize       = 4  // Байт случайного значения, делающего callback данные каждой отправки уникальными
+	macSize         = 8  // Байт усеченной подписи HMAC-SHA256
+	maxCallbackData = 64 // Лимит Telegram на длину callback данных кнопки
+)
+
+// ErrInvalidCallback callback данные повреждены, подделаны или выпущены с другим ключом
+var ErrInvalidCallback = errors.New("invalid callback data")
+
+// AnswerCallback данные кнопки ответа на вопрос
+type AnswerCallback struct {
+	UserTestID int    // Назначение, в котором показан вопрос
+	QuestionID int    // Вопрос, на который отвечает кандидат
+	Option     int    // Индекс варианта в TestOptions (answer и toggle)
+	Selected   uint64 // Отмеченные варианты вопроса с несколькими ответами (toggle и confirm)
+}
+
+// CallbackSigner кодирует данные кнопок ответа в компактный вид и подписывает их HMAC, чтобы кандидат
+// не мог отправить произвольный ответ или ответить за другого кандидата. Данные кнопки вместе с префиксом
+// укладываются в лимит Telegram 64 байта при любой длине текста вариантов.
+type CallbackSigner struct {
+	key []byte
+}
+
+// NewCallbackSigner создает экземпляр CallbackSigner с ключом подписи
+func NewCallbackSigner(key []byte) *CallbackSigner {
+	return &CallbackSigner{key: key}
+}
+
+// Encode возвращает callback данные вида <действие>_<base64url(ID назначения, ID вопроса, вариант, отметки, nonce, подпись)>
+func (s *CallbackSigner) Encode(action string, callback AnswerCallback) string {
+	payload := make([]byte, 0, 4*binary.MaxVarintLen64+nonceSize+macSize)
+	payload = binary.AppendUvarint(payload, uint64(callback.UserTestID))
+	payload = binary.AppendUvarint(payload, uint64(callback.QuestionID))
+	payload = binary.AppendUvarint(payload, uint64(callback.Option))
+	payload = binary.AppendUvarint(payload, callback.Selected)
+	payload = binary.BigEndian.AppendUint32(payload, rand.Uint32())
+	payload = append(payload, s.sign(action, payload)...)
+
+	return action + "_" + base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// Decode проверяет подпись callback данных и возвращает действие и данные кнопки
+func (s *CallbackSigner) Decode(data string) (string, AnswerCallback, error) {
+	if len(data) > maxCallbackData {
+		return "", AnswerCallback{}, ErrInvalidCallback
+	}
+
+	action, encoded, found := strings.Cut(data, "_")
+	if !found {
+		return "", AnswerCallback{}, ErrInvalidCallback
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) <= nonceSize+macSize {
+		return "", AnswerCallback{}, ErrInvalidCallback
+	}
+
+	signed, mac := payload[:len(payload)-macSize], payload[len(payload)-macSize:]
+	if !hmac.Equal(mac, s.sign(action, signed)) {
+		return "", AnswerCallback{}, ErrInvalidCallback
+	}
+
+	fields := signed[:len(signed)-nonceSize]
+	var values [4]uint64
+	for i := range values {
+		value, n := binary.Uvarint(fields)
+		if n <= 0 {
+			return "", AnswerCallback{}, ErrInvalidCallback
+		}
+		values[i] = value
+		fields = fields[n:]
+	}
+	if len(fields) != 0 {
+		return "", AnswerCallback{}, ErrInvalidCallback
+	}
+
+	return action, AnswerCallback{
+		UserTestID: int(values[0]),
+		QuestionID: int(values[1]),
+		Option:     int(values[2]),
+		Selected:   values[3],
+	}, nil
+}
+
+// sign возвращает усеченную подпись действия и данных кнопки
+func (s *CallbackSigner) sign(action string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(action))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)[:macSize]
+}
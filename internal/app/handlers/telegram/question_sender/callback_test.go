@@ -0,0 +1,67 @@
+package question_sender
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCallbackSignerDecode(t *testing.T) {
+	signer := NewCallbackSigner([]byte("test-key"))
+	callback := AnswerCallback{
+		UserTestID: math.MaxInt32,
+		QuestionID: math.MaxInt32,
+		Option:     MaxMultipleOptions - 1,
+		Selected:   math.MaxUint64,
+	}
+	data := signer.Encode(ActionConfirm, callback)
+
+	// flipByte меняет один байт подписанных данных, оставляя их корректным base64
+	flipByte := func(data string) string {
+		action, encoded, _ := strings.Cut(data, "_")
+		payload, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			t.Fatalf("failed to decode payload: %v", err)
+		}
+		payload[0] ^= 0x01
+		return action + "_" + base64.RawURLEncoding.EncodeToString(payload)
+	}
+
+	tests := []struct {
+		name    string
+		signer  *CallbackSigner
+		data    string
+		wantErr bool
+	}{
+		{name: "round trip", signer: signer, data: data},
+		{name: "flipped byte", signer: signer, data: flipByte(data), wantErr: true},
+		{name: "changed action", signer: signer, data: ActionAnswer + strings.TrimPrefix(data, ActionConfirm), wantErr: true},
+		{name: "wrong key", signer: NewCallbackSigner([]byte("other-key")), data: data, wantErr: true},
+		{name: "longer than 64 bytes", signer: signer, data: data + strings.Repeat("A", maxCallbackData), wantErr: true},
+		{name: "no action", signer: signer, data: strings.TrimPrefix(data, ActionConfirm+"_"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, got, err := tt.signer.Decode(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCallback) {
+					t.Fatalf("Decode() error = %v, want %v", err, ErrInvalidCallback)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode() unexpected error: %v", err)
+			}
+			if action != ActionConfirm || got != callback {
+				t.Fatalf("Decode() = %q, %+v, want %q, %+v", action, got, ActionConfirm, callback)
+			}
+		})
+	}
+
+	if len(data) > maxCallbackData {
+		t.Fatalf("encoded callback is %d bytes, Telegram limit is %d", len(data), maxCallbackData)
+	}
+}
@@ -0,0 +1,116 @@
+package question_sender
+
+import (
+	"github.com/IT-Nick/internal/domain/model"
+	"regexp"
+	"strings"
+)
+
+// lineBreak разделитель строк текста вопроса. Тексты, добавленные миграциями, содержат "\n" как два символа.
+var lineBreak = regexp.MustCompile(`\r?\n|\\n`)
+
+// displayOption вариант ответа в порядке показа кандидату
+type displayOption struct {
+	id   int    // Индекс варианта в TestOptions, передается в callback вместо позиции на экране
+	text string // Текст кнопки
+}
+
+// displayOptions возвращает варианты ответа в порядке показа (question.OptionOrder).
+// Если варианты - метки, расписанные в тексте вопроса ("A)", "B)"), метки на кнопках остаются по порядку,
+// а перемешиваются значения вариантов в тексте (см. DisplayText).
+func displayOptions(question model.Question) []displayOption {
+	order := optionOrder(question)
+	_, _, _, labeled := labeledLines(question)
+
+	options := make([]displayOption, 0, len(order))
+	for position, id := range order {
+		text := question.TestOptions[id]
+		if labeled {
+			text = question.TestOptions[position]
+		}
+		options = append(options, displayOption{id: id, text: text})
+	}
+	return options
+}
+
+// OptionText возвращает вариант с индексом id в TestOptions так, как он подписан на кнопке у кандидата
+func OptionText(question model.Question, id int) string {
+	for _, option := range displayOptions(question) {
+		if option.id == id {
+			return option.text
+		}
+	}
+	return ""
+}
+
+// DisplayText возвращает текст вопроса в том виде, в котором его видит кандидат: если варианты - метки,
+// расписанные в тексте, строки вариантов переставляются в порядке показа под метками "A)", "B)", ...
+func DisplayText(question model.Question) string {
+	if question.OptionOrder == nil {
+		return question.QuestionText
+	}
+	lines, breaks, optionLines, labeled := labeledLines(question)
+	if !labeled {
+		return question.QuestionText
+	}
+
+	shuffled := append([]string(nil), lines...)
+	for position, id := range question.OptionOrder {
+		value := strings.TrimPrefix(lines[optionLines[id]], question.TestOptions[id])
+		shuffled[optionLines[position]] = question.TestOptions[position] + value
+	}
+
+	var text strings.Builder
+	for i, line := range shuffled {
+		text.WriteString(line)
+		if i < len(breaks) {
+			text.WriteString(breaks[i])
+		}
+	}
+	return text.String()
+}
+
+// optionOrder возвращает порядок показа вариантов, по умолчанию - исходный порядок TestOptions
+func optionOrder(question model.Question) []int {
+	if len(question.OptionOrder) == len(question.TestOptions) {
+		return question.OptionOrder
+	}
+	order := make([]int, len(question.TestOptions))
+	for i := range order {
+		order[i] = i
+	}
+	return order
+}
+
+// labeledLines разбивает текст вопроса на строки и находит строку каждого варианта-метки ("A) текст варианта").
+// labeled равен false, если хотя бы один вариант не найден в тексте отдельной строкой.
+func labeledLines(question model.Question) (lines []string, breaks []string, optionLines []int, labeled bool) {
+	if len(question.TestOptions) == 0 {
+		return nil, nil, nil, false
+	}
+
+	last := 0
+	for _, loc := range lineBreak.FindAllStringIndex(question.QuestionText, -1) {
+		lines = append(lines, question.QuestionText[last:loc[0]])
+		breaks = append(breaks, question.QuestionText[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	lines = append(lines, question.QuestionText[last:])
+
+	optionLines = make([]int, len(question.TestOptions))
+	used := make(map[int]bool, len(question.TestOptions))
+	for i, option := range question.TestOptions {
+		optionLines[i] = -1
+		for j, line := range lines {
+			if !used[j] && strings.HasPrefix(line, option+" ") {
+				optionLines[i] = j
+				used[j] = true
+				break
+			}
+		}
+		if optionLines[i] < 0 {
+			return nil, nil, nil, false
+		}
+	}
+	return lines, breaks, optionLines, true
+}
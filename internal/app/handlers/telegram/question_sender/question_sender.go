@@ -0,0 +1,252 @@
+package question_sender
+
+import (
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"gopkg.in/telebot.v4"
+	"strings"
+	"time"
+)
+
+// MaxMultipleOptions максимальное число вариантов вопроса с несколькими ответами:
+// отмеченные варианты передаются битовой маской uint64 в callback данных и в user_tests.question_selection
+const MaxMultipleOptions = 64
+
+// ErrTooManyOptions у вопроса с несколькими ответами больше MaxMultipleOptions вариантов
+var ErrTooManyOptions = errors.New("too many options for multiple choice question")
+
+// QuestionSender отправляет кандидату вопросы теста с клавиатурой, соответствующей типу ответа
+type QuestionSender struct {
+	bot    *telebot.Bot
+	signer *CallbackSigner // Подписывает callback данные кнопок ответа
+}
+
+// NewQuestionSender создает новый экземпляр QuestionSender
+func NewQuestionSender(bot *telebot.Bot, signer *CallbackSigner) *QuestionSender {
+	return &QuestionSender{bot: bot, signer: signer}
+}
+
+// Navigation положение вопроса в тесте со свободной навигацией
+type Navigation struct {
+	Index    int    // Индекс вопроса в тесте, начиная с 0
+	Total    int    // Количество вопросов в тесте
+	Answer   string // Сохраненный ответ кандидата в виде для показа, пустой для пропущенного вопроса
+	Selected uint64 // Отмеченные ранее варианты вопроса с несколькими ответами
+}
+
+// Send отправляет вопрос назначения userTestID пользователю с порядковым номером. Для вопроса с ограничением времени
+// в сообщение добавляется обратный отсчет, который затем обновляет EditCountdown.
+func (s *QuestionSender) Send(recipient *telebot.User, userTestID int, question model.Question, questionNumber int) (*telebot.Message, error) {
+	countdown := ""
+	if question.TimeLimitSeconds != nil {
+		countdown = FormatCountdown(time.Duration(*question.TimeLimitSeconds) * time.Second)
+	}
+	text, markup, err := s.render(userTestID, question, fmt.Sprintf("Вопрос %d", questionNumber), 0, countdown)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.bot.Send(recipient, text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeMarkdown,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send question: %w", err)
+	}
+
+	return message, nil
+}
+
+// EditCountdown обновляет обратный отсчет в сообщении с вопросом.
+// selected - отмеченные варианты вопроса с несколькими ответами, они сохраняются в клавиатуре.
+func (s *QuestionSender) EditCountdown(message *telebot.Message, userTestID int, question model.Question, questionNumber int, selected uint64, timeLeft time.Duration) error {
+	text, markup, err := s.render(userTestID, question, fmt.Sprintf("Вопрос %d", questionNumber), selected, FormatCountdown(timeLeft))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.bot.Edit(message, text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeMarkdown,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update question countdown: %w", err)
+	}
+
+	return nil
+}
+
+// Expire убирает кнопки ответа из сообщения с вопросом, время на который истекло
+func (s *QuestionSender) Expire(message *telebot.Message, question model.Question, questionNumber int) error {
+	text := fmt.Sprintf("❓ *Вопрос %d:*\n%s\n\n⏰ Время на ответ истекло, вопрос засчитан без ответа.", questionNumber, DisplayText(question))
+
+	_, err := s.bot.Edit(message, text, &telebot.SendOptions{
+		ParseMode: telebot.ModeMarkdown,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark question as expired: %w", err)
+	}
+
+	return nil
+}
+
+// FormatCountdown формирует строку с оставшимся временем на ответ
+func FormatCountdown(timeLeft time.Duration) string {
+	seconds := int(max(timeLeft, 0).Round(time.Second).Seconds())
+	return fmt.Sprintf("⏱ Осталось на ответ: *%d:%02d*", seconds/60, seconds%60)
+}
+
+// SendNavigable отправляет вопрос теста со свободной навигацией новым сообщением
+func (s *QuestionSender) SendNavigable(recipient *telebot.User, userTestID int, question model.Question, nav Navigation) error {
+	text, markup, err := s.renderNavigable(userTestID, question, nav)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.bot.Send(recipient, text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeMarkdown,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send question: %w", err)
+	}
+
+	return nil
+}
+
+// EditNavigable показывает вопрос теста со свободной навигацией в уже отправленном сообщении
+func (s *QuestionSender) EditNavigable(message *telebot.Message, userTestID int, question model.Question, nav Navigation) error {
+	text, markup, err := s.renderNavigable(userTestID, question, nav)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.bot.Edit(message, text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeMarkdown,
+		ReplyMarkup: markup,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to edit question: %w", err)
+	}
+
+	return nil
+}
+
+// AddNavigation добавляет к клавиатуре вопроса кнопки перехода между вопросами и к списку вопросов
+func (s *QuestionSender) AddNavigation(markup *telebot.ReplyMarkup, nav Navigation) {
+	var row []telebot.InlineButton
+	if nav.Index > 0 {
+		row = append(row, telebot.InlineButton{Text: "◀️ Назад", Data: fmt.Sprintf("nav_goto_%d", nav.Index-1)})
+	}
+
+	nextText := "Пропустить ⏭"
+	if nav.Answer != "" {
+		nextText = "Далее ▶️"
+	}
+	nextData := fmt.Sprintf("nav_goto_%d", nav.Index+1)
+	if nav.Index+1 >= nav.Total {
+		nextData = "nav_summary"
+	}
+	row = append(row, telebot.InlineButton{Text: nextText, Data: nextData})
+
+	markup.InlineKeyboard = append(markup.InlineKeyboard, row,
+		[]telebot.InlineButton{{Text: "📋 Все вопросы", Data: "nav_summary"}})
+}
+
+// render формирует текст вопроса и клавиатуру, соответствующую типу ответа.
+// countdown - строка обратного отсчета для вопроса с ограничением времени, пустая для остальных вопросов.
+func (s *QuestionSender) render(userTestID int, question model.Question, title string, selected uint64, countdown string) (string, *telebot.ReplyMarkup, error) {
+	var messageBuilder strings.Builder
+	messageBuilder.WriteString(fmt.Sprintf("❓ *%s:*\n%s\n\n", title, DisplayText(question)))
+
+	var markup *telebot.ReplyMarkup
+	switch question.AnswerType {
+	case model.AnswerTypeMultiple:
+		messageBuilder.WriteString("_Выберите все подходящие варианты и нажмите «Подтвердить»._")
+		var err error
+		markup, err = s.MultipleMarkup(userTestID, question, selected)
+		if err != nil {
+			return "", nil, err
+		}
+	case model.AnswerTypeText:
+		// Ответ на текстовый вопрос кандидат отправляет следующим сообщением, кнопки не нужны
+		messageBuilder.WriteString("_Отправьте ответ одним сообщением._")
+	default:
+		markup = s.singleMarkup(userTestID, question)
+	}
+
+	if countdown != "" {
+		messageBuilder.WriteString("\n\n" + countdown)
+	}
+
+	return messageBuilder.String(), markup, nil
+}
+
+// renderNavigable формирует вопрос с сохраненным ответом и кнопками навигации
+func (s *QuestionSender) renderNavigable(userTestID int, question model.Question, nav Navigation) (string, *telebot.ReplyMarkup, error) {
+	text, markup, err := s.render(userTestID, question, fmt.Sprintf("Вопрос %d из %d", nav.Index+1, nav.Total), nav.Selected, "")
+	if err != nil {
+		return "", nil, err
+	}
+	if nav.Answer != "" {
+		text += fmt.Sprintf("\n\n✏️ Ваш ответ: %s\n_Ответ можно изменить до отправки теста._", markdownEscaper.Replace(nav.Answer))
+	}
+
+	if markup == nil {
+		markup = s.bot.NewMarkup()
+	}
+	s.AddNavigation(markup, nav)
+
+	return text, markup, nil
+}
+
+// markdownEscaper экранирует служебные символы Markdown в тексте, введенном кандидатом
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// singleMarkup формирует клавиатуру для вопроса с одним правильным ответом
+func (s *QuestionSender) singleMarkup(userTestID int, question model.Question) *telebot.ReplyMarkup {
+	markup := s.bot.NewMarkup()
+	if len(question.TestOptions) == 0 {
+		return markup
+	}
+
+	rows := make([]telebot.Row, 0, len(question.TestOptions))
+	for i, option := range displayOptions(question) {
+		btnText := fmt.Sprintf("%d. %s", i+1, option.text)
+		// В callbackData передается ID варианта вместо позиции на экране, текст ответа бот берет из вопроса
+		callbackData := s.signer.Encode(ActionAnswer, AnswerCallback{UserTestID: userTestID, QuestionID: question.ID, Option: option.id})
+		rows = append(rows, markup.Row(markup.Data(btnText, callbackData)))
+	}
+	markup.Inline(rows...)
+
+	return markup
+}
+
+// MultipleMarkup формирует клавиатуру для вопроса с несколькими правильными ответами.
+// selected - битовая маска уже отмеченных вариантов (бит соответствует индексу в TestOptions), она же передается
+// в подписанном callback, поэтому текущий выбор не нужно хранить на стороне бота.
+// Вопрос с числом вариантов больше MaxMultipleOptions не показывается: отметки старших вариантов не поместились бы в маску.
+func (s *QuestionSender) MultipleMarkup(userTestID int, question model.Question, selected uint64) (*telebot.ReplyMarkup, error) {
+	if len(question.TestOptions) > MaxMultipleOptions {
+		return nil, fmt.Errorf("%w: question %d has %d options, maximum %d", ErrTooManyOptions, question.ID, len(question.TestOptions), MaxMultipleOptions)
+	}
+
+	markup := s.bot.NewMarkup()
+
+	rows := make([]telebot.Row, 0, len(question.TestOptions)+1)
+	for i, option := range displayOptions(question) {
+		mark := "☐"
+		if selected&(1<<uint(option.id)) != 0 {
+			mark = "☑️"
+		}
+		btnText := fmt.Sprintf("%s %d. %s", mark, i+1, option.text)
+		callbackData := s.signer.Encode(ActionToggle, AnswerCallback{UserTestID: userTestID, QuestionID: question.ID, Option: option.id, Selected: selected})
+		rows = append(rows, markup.Row(markup.Data(btnText, callbackData)))
+	}
+	confirmData := s.signer.Encode(ActionConfirm, AnswerCallback{UserTestID: userTestID, QuestionID: question.ID, Selected: selected})
+	rows = append(rows, markup.Row(markup.Data("✅ Подтвердить", confirmData)))
+	markup.Inline(rows...)
+
+	return markup, nil
+}
@@ -0,0 +1,263 @@
+package review_answers_handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	testsRepo "github.com/IT-Nick/internal/domain/tests/repository"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	usersService "github.com/IT-Nick/internal/domain/users/service"
+	"gopkg.in/telebot.v4"
+	"html"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ReviewAnswersHandler проводит менеджера по очереди ответов, ожидающих ручной проверки
+type ReviewAnswersHandler struct {
+	bot          *telebot.Bot
+	userService  *usersService.UserService
+	testService  *testsService.TestService
+	commentState map[int64]int // telegram ID менеджера -> ID ответа, к которому ожидается комментарий
+	mutex        sync.Mutex
+}
+
+// NewReviewAnswersHandler возвращает структуру обработчика ручной проверки ответов
+func NewReviewAnswersHandler(
+	bot *telebot.Bot,
+	userService *usersService.UserService,
+	testService *testsService.TestService,
+	commentState map[int64]int,
+) *ReviewAnswersHandler {
+	return &ReviewAnswersHandler{
+		bot:          bot,
+		userService:  userService,
+		testService:  testService,
+		commentState: commentState,
+	}
+}
+
+// Handle показывает менеджеру следующий ответ, ожидающий проверки
+func (h *ReviewAnswersHandler) Handle(c telebot.Context) error {
+	ctx := context.Background()
+	if c.Callback() != nil {
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+	}
+
+	allowed, err := h.canReview(ctx, c.Sender().Username)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при проверке прав: %v", err))
+	}
+	if !allowed {
+		return c.Send("У вас нет прав на проверку ответов.")
+	}
+
+	return h.sendNext(ctx, c)
+}
+
+// HandleCallback обрабатывает кнопки карточки ответа (grade_ok_<id>, grade_fail_<id>, grade_comment_<id>, grade_next)
+func (h *ReviewAnswersHandler) HandleCallback(c telebot.Context) error {
+	ctx := context.Background()
+
+	cleanedData := strings.TrimSpace(c.Callback().Data)
+	cleanedData = strings.ReplaceAll(cleanedData, "\f", "")
+	cleanedData = strings.ReplaceAll(cleanedData, "\\f", "")
+
+	allowed, err := h.canReview(ctx, c.Sender().Username)
+	if err != nil || !allowed {
+		return c.Respond(&telebot.CallbackResponse{Text: "У вас нет прав на проверку ответов."})
+	}
+
+	if cleanedData == "grade_next" {
+		return h.Handle(c)
+	}
+
+	parts := strings.Split(cleanedData, "_")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid callback data: %s", cleanedData)
+	}
+	answerID, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return fmt.Errorf("invalid answer ID: %w", err)
+	}
+
+	switch parts[1] {
+	case "ok":
+		return h.grade(ctx, c, answerID, true)
+	case "fail":
+		return h.grade(ctx, c, answerID, false)
+	case "comment":
+		h.mutex.Lock()
+		h.commentState[c.Sender().ID] = answerID
+		h.mutex.Unlock()
+
+		if err := c.Respond(); err != nil {
+			log.Printf("Failed to respond to callback: %v", err)
+		}
+		return c.Send("Введите комментарий к оценке одним сообщением.")
+	}
+
+	return nil
+}
+
+// AwaitsComment сообщает, что менеджер сейчас вводит комментарий к оценке
+func (h *ReviewAnswersHandler) AwaitsComment(telegramID int64) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	_, exists := h.commentState[telegramID]
+	return exists
+}
+
+// HandleComment сохраняет комментарий к проверенному ответу и показывает следующий ответ
+func (h *ReviewAnswersHandler) HandleComment(c telebot.Context) error {
+	ctx := context.Background()
+	telegramID := c.Sender().ID
+
+	h.mutex.Lock()
+	answerID, exists := h.commentState[telegramID]
+	delete(h.commentState, telegramID)
+	h.mutex.Unlock()
+
+	if !exists {
+		return nil
+	}
+
+	comment := strings.TrimSpace(c.Message().Text)
+	if comment == "" {
+		return c.Send("Комментарий не может быть пустым.")
+	}
+
+	if err := h.testService.SaveReviewComment(ctx, answerID, comment); err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при сохранении комментария: %v", err))
+	}
+
+	if err := c.Send("💬 Комментарий сохранен."); err != nil {
+		return err
+	}
+	return h.sendNext(ctx, c)
+}
+
+// grade сохраняет оценку ответа и уведомляет HR, если проверка теста завершена
+func (h *ReviewAnswersHandler) grade(ctx context.Context, c telebot.Context, answerID int, isCorrect bool) error {
+	userTestID, completed, err := h.testService.ReviewAnswer(ctx, answerID, c.Sender().Username, isCorrect)
+	if errors.Is(err, testsRepo.ErrAnswerAlreadyReviewed) {
+		return c.Respond(&telebot.CallbackResponse{Text: "Этот ответ уже проверен."})
+	}
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Ошибка при сохранении оценки: %v", err)})
+	}
+
+	// Заменяем кнопки оценки на комментарий и переход к следующему ответу
+	markup := h.bot.NewMarkup()
+	markup.Inline(
+		markup.Row(markup.Data("💬 Добавить комментарий", fmt.Sprintf("grade_comment_%d", answerID))),
+		markup.Row(markup.Data("➡️ Следующий ответ", "grade_next")),
+	)
+	if _, err := h.bot.EditReplyMarkup(c.Message(), markup); err != nil {
+		log.Printf("Failed to update review keyboard: %v", err)
+	}
+
+	if completed {
+		h.notifyReviewCompleted(ctx, userTestID)
+	}
+
+	verdict := "❌ Ответ отмечен как неверный."
+	if isCorrect {
+		verdict = "✅ Ответ отмечен как верный."
+	}
+	return c.Respond(&telebot.CallbackResponse{Text: verdict})
+}
+
+// sendNext отправляет карточку следующего ответа, ожидающего проверки
+func (h *ReviewAnswersHandler) sendNext(ctx context.Context, c telebot.Context) error {
+	review, pendingCount, err := h.testService.GetNextPendingReview(ctx)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Ошибка при получении ответов на проверку: %v", err))
+	}
+	if review == nil {
+		return c.Send("🎉 Нет ответов, ожидающих проверки.")
+	}
+
+	text := fmt.Sprintf(
+		"📝 <b>Ответ на проверку</b> (осталось: %d)\n\nКандидат: @%s\nТест: <b>%s</b>\n\n<b>Вопрос:</b>\n%s\n\n<b>Эталонный ответ:</b>\n%s\n\n<b>Ответ кандидата:</b>\n%s",
+		pendingCount,
+		html.EscapeString(review.Username),
+		html.EscapeString(review.TestName),
+		html.EscapeString(review.QuestionText),
+		html.EscapeString(review.CorrectAnswer),
+		html.EscapeString(review.Answer.UserAnswer),
+	)
+
+	markup := h.bot.NewMarkup()
+	markup.Inline(markup.Row(
+		markup.Data("✅ Верно", fmt.Sprintf("grade_ok_%d", review.Answer.ID)),
+		markup.Data("❌ Неверно", fmt.Sprintf("grade_fail_%d", review.Answer.ID)),
+	))
+
+	return c.Send(text, &telebot.SendOptions{
+		ParseMode:   telebot.ModeHTML,
+		ReplyMarkup: markup,
+	})
+}
+
+// notifyReviewCompleted отправляет HR, назначившему тест, итоговый результат после ручной проверки
+func (h *ReviewAnswersHandler) notifyReviewCompleted(ctx context.Context, userTestID int) {
+	userTest, err := h.userService.GetUserTestByID(ctx, userTestID)
+	if err != nil {
+		log.Printf("Failed to get user test %d after review: %v", userTestID, err)
+		return
+	}
+	hrManager, err := h.userService.GetUserByID(ctx, userTest.AssignedBy)
+	if err != nil || hrManager.TelegramID == nil {
+		log.Printf("Failed to get HR manager for user test %d: %v", userTestID, err)
+		return
+	}
+	candidate, err := h.userService.GetUserByID(ctx, userTest.UserID)
+	if err != nil {
+		log.Printf("Failed to get candidate for user test %d: %v", userTestID, err)
+		return
+	}
+	test, err := h.testService.GetTestByID(ctx, userTest.TestID)
+	if err != nil {
+		log.Printf("Failed to get test for user test %d: %v", userTestID, err)
+		return
+	}
+
+	message := fmt.Sprintf(
+		"✅ Проверка ответов кандидата <b>%s</b> по тесту <b>%s</b> завершена.\nПравильных ответов: <b>%d</b> из <b>%d</b>.",
+		html.EscapeString(candidate.TelegramUsername),
+		html.EscapeString(test.TestName),
+		userTest.CorrectAnswersCount,
+		test.QuestionCount,
+	)
+	_, err = h.bot.Send(&telebot.User{ID: *hrManager.TelegramID}, message, &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+	if err != nil {
+		log.Printf("Failed to notify HR about completed review: %v", err)
+	}
+}
+
+// canReview проверяет, что у пользователя есть право проверять ответы
+func (h *ReviewAnswersHandler) canReview(ctx context.Context, username string) (bool, error) {
+	permissions, err := h.userService.GetPermissionsForUser(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	// Ручная проверка ответов доступна сотрудникам, которые назначают тесты
+	return slices.Contains(permissions, model.PermissionAssignTest), nil
+}
+
+// GetHandlerFunc возвращает обработчик в формате telebot.HandlerFunc
+func (h *ReviewAnswersHandler) GetHandlerFunc() telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		return h.Handle(c)
+	}
+}
@@ -2,15 +2,20 @@ package start_handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/IT-Nick/internal/app/handlers/telegram/start_test_handler"
 	messageService "github.com/IT-Nick/internal/domain/messages/service"
 	"github.com/IT-Nick/internal/domain/model"
 	rolesService "github.com/IT-Nick/internal/domain/roles/service"
 	testService "github.com/IT-Nick/internal/domain/tests/service"
 	usersService "github.com/IT-Nick/internal/domain/users/service"
 	"gopkg.in/telebot.v4"
+	"log"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // StartHandler структура для обработки команды /start
@@ -19,6 +24,7 @@ type StartHandler struct {
 	messageService *messageService.MessageService
 	roleService    *rolesService.RoleService
 	testService    *testService.TestService
+	location       *time.Location // Часовой пояс дат в сообщениях
 }
 
 // NewStartHandler возвращает структуру обработчика
@@ -27,12 +33,14 @@ func NewStartHandler(
 	messageService *messageService.MessageService,
 	roleService *rolesService.RoleService,
 	testService *testService.TestService,
+	location *time.Location,
 ) *StartHandler {
 	return &StartHandler{
 		userService:    userService,
 		messageService: messageService,
 		roleService:    roleService,
 		testService:    testService,
+		location:       location,
 	}
 }
 
@@ -55,51 +63,61 @@ func (h *StartHandler) Handle(c telebot.Context) error {
 		return c.Send(fmt.Sprintf("Failed to process user: %v", err))
 	}
 
+	// Применяем отложенное назначение роли до построения клавиатуры, чтобы меню отражало новую роль
+	promotion, err := h.userService.ApplyPendingPromotion(ctx, userID, username)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Failed to apply pending promotion: %v", err))
+	}
+	if promotion != nil {
+		h.notifyPromoter(ctx, c.Bot(), promotion)
+	}
+
 	user, err := h.userService.GetUserByID(ctx, userID)
 	if err != nil {
 		return c.Send(fmt.Sprintf("Failed to process user: %v", err))
 	}
 
-	// Проверяем параметры start (например, из ссылки/QR-кода)
+	// Проверяем параметры start (например, из ссылки/QR-кода): test_<id>_<token>.
+	// В старых ссылках после токена идет username HR, он игнорируется: автор ссылки берется из test_links.
 	startParam := c.Data()
 	var testID int
-	var assignedBy string
-	var assignedTests []model.Test
+	var assignedTests []model.Assignment
 	if startParam != "" && strings.HasPrefix(startParam, "test_") {
-		parts := strings.Split(startParam, "_")
-		if len(parts) == 4 && parts[0] == "test" {
+		parts := strings.SplitN(startParam, "_", 4)
+		if len(parts) >= 3 && parts[0] == "test" {
 			testID, err = strconv.Atoi(parts[1])
 			if err != nil {
 				return c.Send("Неверный формат ID теста в ссылке.")
 			}
-			//token := parts[2]
-			assignedBy = parts[3]
+			token := parts[2]
 
-			//// Проверяем токен
-			//valid, err := h.testService.ValidateTestLink(ctx, testID, token)
-			//if err != nil || !valid {
-			//	return c.Send("Недействительная или истекшая ссылка на тест.")
-			//}
+			// Проверяем токен
+			link, err := h.testService.ValidateTestLink(ctx, testID, token)
+			if err != nil {
+				if isTestLinkError(err) {
+					return c.Send("Недействительная или истекшая ссылка на тест.")
+				}
+				return c.Send(fmt.Sprintf("Ошибка при проверке ссылки: %v", err))
+			}
 
 			// Проверяем, есть ли назначенные тесты
-			assignedTests, err = h.testService.GetAvailableTestsForUser(ctx, username)
+			assignedTests, err = h.testService.GetAvailableAssignmentsForUser(ctx, username)
 			if err != nil {
 				return c.Send(fmt.Sprintf("Ошибка при получении тестов: %v", err))
 			}
 
 			if len(assignedTests) == 0 {
-				// Назначаем тест пользователю
-				_, err = h.testService.AssignTestToUser(ctx, userID, testID, assignedBy)
+				// Назначаем тест пользователю, использование ссылки засчитывается вместе с назначением
+				_, err = h.testService.AssignTestByLink(ctx, link, userID)
 				if err != nil {
-					// Если пользователь не найден, используем отложенное назначение
-					_, err = h.testService.AssignPendingTest(ctx, username, testID, assignedBy)
-					if err != nil {
-						return c.Send(fmt.Sprintf("Ошибка при создании отложенного назначения теста: %v", err))
+					if isTestLinkError(err) {
+						return c.Send("Недействительная или истекшая ссылка на тест.")
 					}
+					return c.Send(fmt.Sprintf("Ошибка при назначении теста: %v", err))
 				}
 
 				// Обновляем список назначенных тестов
-				assignedTests, err = h.testService.GetAvailableTestsForUser(ctx, username)
+				assignedTests, err = h.testService.GetAvailableAssignmentsForUser(ctx, username)
 				if err != nil {
 					return c.Send(fmt.Sprintf("Ошибка при получении тестов: %v", err))
 				}
@@ -107,7 +125,7 @@ func (h *StartHandler) Handle(c telebot.Context) error {
 		}
 	} else {
 		// Если нет параметров start, просто проверяем назначенные тесты
-		assignedTests, err = h.testService.GetAvailableTestsForUser(ctx, username)
+		assignedTests, err = h.testService.GetAvailableAssignmentsForUser(ctx, username)
 		if err != nil {
 			return c.Send(fmt.Sprintf("Failed to retrieve assigned tests: %v", err))
 		}
@@ -126,7 +144,8 @@ func (h *StartHandler) Handle(c telebot.Context) error {
 	}
 
 	var welcomeMessage string
-	if len(assignedTests) > 0 {
+	switch {
+	case len(assignedTests) == 1:
 		// Если тест назначен, используем welcome_message_user
 		test := assignedTests[0]
 		welcomeMessageKey := "welcome_message_user"
@@ -135,31 +154,39 @@ func (h *StartHandler) Handle(c telebot.Context) error {
 			return c.Send(fmt.Sprintf("Failed to retrieve welcome message: %v", err))
 		}
 
-		// Получаем информацию о тесте
-		testName := test.TestName
-		duration := test.Duration
-		questionCount := test.QuestionCount
-
 		// Получаем информацию о HR-менеджере
-		userTest, err := h.testService.GetUserTestByTestIDAndUsername(ctx, test.ID, username)
-		if err != nil {
-			return c.Send(fmt.Sprintf("Failed to retrieve user test: %v", err))
-		}
-		hrManager, err := h.userService.GetUserByID(ctx, userTest.AssignedBy)
+		hrManager, err := h.userService.GetUserByID(ctx, test.AssignedBy)
 		if err != nil {
 			return c.Send(fmt.Sprintf("Failed to retrieve HR manager: %v", err))
 		}
 		hrManagerName := fmt.Sprintf("%s, @%s", *hrManager.TelegramFirstName, hrManager.TelegramUsername)
 
+		// Окно доступности выводится отдельной строкой после параметров теста
+		availability := ""
+		if window := start_test_handler.FormatAvailabilityWindow(test.AvailabilityWindow, h.location); window != "" {
+			availability = "\n🕙 Тест можно начать " + window + "."
+		}
+
 		// Форматируем сообщение с параметрами
 		welcomeMessage = fmt.Sprintf(welcomeMessage,
 			*user.TelegramFirstName,
-			testName,
+			test.TestName,
 			hrManagerName,
-			duration,
-			questionCount,
+			test.Duration,
+			test.QuestionCount,
+			availability,
 		)
-	} else {
+	case len(assignedTests) > 1:
+		// Если назначено несколько тестов, перечисляем их все
+		welcomeMessage, err = h.messageService.GetMessageByKey(ctx, "welcome_message_user_multiple")
+		if err != nil {
+			return c.Send(fmt.Sprintf("Failed to retrieve welcome message: %v", err))
+		}
+		welcomeMessage = fmt.Sprintf(welcomeMessage,
+			*user.TelegramFirstName,
+			start_test_handler.AssignmentList(assignedTests, h.location),
+		)
+	default:
 		// Если теста нет, используем welcome_message_without_assign
 		welcomeMessageKey := "welcome_message_without_assign"
 		welcomeMessage, err = h.messageService.GetMessageByKey(ctx, welcomeMessageKey)
@@ -173,6 +200,14 @@ func (h *StartHandler) Handle(c telebot.Context) error {
 		)
 	}
 
+	// Общая кнопка начала теста заменяется кнопками для каждого назначенного теста
+	if len(assignedTests) > 0 {
+		keyboard = slices.DeleteFunc(keyboard, func(row []telebot.InlineButton) bool {
+			return len(row) == 1 && row[0].Unique == model.StartTestKey
+		})
+		keyboard = append(start_test_handler.StartButtons(buttonsMessages[model.StartTestKey], assignedTests), keyboard...)
+	}
+
 	// Отправляем сообщение с клавиатурой
 	return c.Send(welcomeMessage, &telebot.SendOptions{
 		ParseMode: telebot.ModeHTML,
@@ -182,6 +217,35 @@ func (h *StartHandler) Handle(c telebot.Context) error {
 	})
 }
 
+// notifyPromoter сообщает назначившему роль, что отложенное назначение применено
+func (h *StartHandler) notifyPromoter(ctx context.Context, bot telebot.API, promotion *model.PendingPromotion) {
+	promoter, err := h.userService.GetUserByID(ctx, promotion.PromotedBy)
+	if err != nil || promoter.TelegramID == nil {
+		log.Printf("Failed to find promoter %d: %v", promotion.PromotedBy, err)
+		return
+	}
+	message, err := h.messageService.GetMessageByKey(ctx, "pending_promotion_applied")
+	if err != nil {
+		log.Printf("Failed to get pending_promotion_applied message: %v", err)
+		return
+	}
+	_, err = bot.Send(&telebot.User{ID: *promoter.TelegramID},
+		fmt.Sprintf(message, promotion.TelegramUsername, model.RoleTitle(promotion.RoleName)),
+		&telebot.SendOptions{ParseMode: telebot.ModeHTML},
+	)
+	if err != nil {
+		log.Printf("Failed to notify promoter %d: %v", promotion.PromotedBy, err)
+	}
+}
+
+// isTestLinkError сообщает, что ссылка на тест недействительна, а не произошла внутренняя ошибка
+func isTestLinkError(err error) bool {
+	return errors.Is(err, testService.ErrTestLinkNotFound) ||
+		errors.Is(err, testService.ErrTestLinkRevoked) ||
+		errors.Is(err, testService.ErrTestLinkExpired) ||
+		errors.Is(err, testService.ErrTestLinkExhausted)
+}
+
 // GetHandlerFunc возвращает обработчик в формате telebot.HandlerFunc
 func (h *StartHandler) GetHandlerFunc() telebot.HandlerFunc {
 	return func(c telebot.Context) error {
@@ -2,19 +2,31 @@ package start_test_handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/IT-Nick/internal/app/handlers/telegram/question_sender"
 	messageService "github.com/IT-Nick/internal/domain/messages/service"
 	"github.com/IT-Nick/internal/domain/model"
+	testRepository "github.com/IT-Nick/internal/domain/tests/repository"
 	testService "github.com/IT-Nick/internal/domain/tests/service"
 	usersService "github.com/IT-Nick/internal/domain/users/service"
 	"github.com/IT-Nick/internal/infra/timer"
 	"gopkg.in/telebot.v4"
+	"html"
 	"log"
-	"math/rand/v2"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// Форматы дат в сообщениях кандидату
+const (
+	dateLayout     = "02.01.2006"
+	timeLayout     = "15:04"
+	dateTimeLayout = dateLayout + " " + timeLayout
+)
+
 // StartTestHandler структура для обработки нажатия кнопки "Начать тест"
 type StartTestHandler struct {
 	bot            *telebot.Bot
@@ -22,6 +34,8 @@ type StartTestHandler struct {
 	messageService *messageService.MessageService
 	userService    *usersService.UserService
 	timerUpdater   *timer.Updater
+	questionSender *question_sender.QuestionSender
+	location       *time.Location // Часовой пояс дат в сообщениях
 }
 
 // NewStartTestHandler возвращает новый экземпляр обработчика
@@ -31,6 +45,8 @@ func NewStartTestHandler(
 	messageService *messageService.MessageService,
 	userService *usersService.UserService,
 	timerUpdater *timer.Updater,
+	signer *question_sender.CallbackSigner,
+	location *time.Location,
 ) *StartTestHandler {
 	return &StartTestHandler{
 		bot:            bot,
@@ -38,225 +54,300 @@ func NewStartTestHandler(
 		messageService: messageService,
 		userService:    userService,
 		timerUpdater:   timerUpdater,
+		questionSender: question_sender.NewQuestionSender(bot, signer),
+		location:       location,
 	}
 }
 
-// sendQuestion отправляет вопрос пользователю с порядковым номером и общим количеством вопросов
-func (h *StartTestHandler) sendQuestion(recipient *telebot.User, question model.Question, questionNumber int) error {
-	// Формируем текст вопроса с порядковым номером и общим количеством вопросов
-	var messageBuilder strings.Builder
-	messageBuilder.WriteString(fmt.Sprintf("❓ *Вопрос %d:*\n%s\n\n", questionNumber, question.QuestionText))
-
-	var buttons []telebot.Btn
-	var markup *telebot.ReplyMarkup
-	markup = h.bot.NewMarkup()
-
-	if question.TestOptions != nil && len(question.TestOptions) > 0 {
-		for i, option := range question.TestOptions {
-			btnText := fmt.Sprintf("%d. %s", i+1, option)
-			callbackData := fmt.Sprintf("answer_%d_%d_%s", question.ID, i, option)
-			btn := markup.Data(btnText, callbackData)
-			buttons = append(buttons, btn)
-		}
-
-		rows := make([]telebot.Row, 0)
-		for _, btn := range buttons {
-			rows = append(rows, markup.Row(btn))
-		}
-		markup.Inline(rows...)
-	}
-
-	_, err := h.bot.Send(recipient, messageBuilder.String(), &telebot.SendOptions{
-		ParseMode:   telebot.ModeMarkdown,
-		ReplyMarkup: markup,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to send question: %w", err)
-	}
-
-	return nil
-}
-
 // Handle обрабатывает callback от кнопки "Начать тест"
 func (h *StartTestHandler) Handle(c telebot.Context) error {
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx := context.Background()
 
 	username := c.Sender().Username
 	userID := c.Sender().ID
 
-	// Получаем доступные тесты для пользователя
-	availableTests, err := h.testService.GetAvailableTestsForUser(ctx, username)
+	// Кнопка начала теста содержит ID назначения; кнопка без ID (меню, старые сообщения)
+	// начинает единственное доступное назначение или предлагает выбрать тест
+	assignmentID, err := strconv.Atoi(c.Data())
 	if err != nil {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при получении тестов: %v", err),
-		})
-	}
-
-	if len(availableTests) == 0 {
-		noTestsMessage, err := h.messageService.GetMessageByKey(ctx, "no_available_tests")
+		assignments, err := h.testService.GetAvailableAssignmentsForUser(ctx, username)
 		if err != nil {
 			return c.Respond(&telebot.CallbackResponse{
-				Text: fmt.Sprintf("Ошибка при получении сообщения: %v", err),
+				Text: fmt.Sprintf("Ошибка при получении тестов: %v", err),
 			})
 		}
-		return c.Respond(&telebot.CallbackResponse{
-			Text: noTestsMessage,
-		})
+
+		switch len(assignments) {
+		case 0:
+			noTestsMessage, err := h.messageService.GetMessageByKey(ctx, "no_available_tests")
+			if err != nil {
+				return c.Respond(&telebot.CallbackResponse{
+					Text: fmt.Sprintf("Ошибка при получении сообщения: %v", err),
+				})
+			}
+			return c.Respond(&telebot.CallbackResponse{
+				Text: noTestsMessage,
+			})
+		case 1:
+			assignmentID = assignments[0].UserTestID
+		default:
+			return h.sendChoice(c, assignments)
+		}
 	}
 
-	test := availableTests[0]
-	// Начинаем тест для пользователя
-	userTestID, err := h.testService.StartTestForUser(ctx, username, test.ID)
-	if err != nil {
+	// Начинаем попытку по выбранному назначению: первую или повторную, если это позволяет политика теста
+	userTestID, err := h.testService.StartAssignment(ctx, username, assignmentID)
+	var cooldownErr *testRepository.RetakeCooldownError
+	var windowErr *testRepository.AvailabilityWindowError
+	switch {
+	case errors.As(err, &windowErr):
 		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при начале теста: %v", err),
+			Text:      fmt.Sprintf("Сейчас тест начать нельзя. Тест можно начать %s.", FormatAvailabilityWindow(windowErr.Window, h.location)),
+			ShowAlert: true,
 		})
-	}
-
-	// Получаем информацию о назначении теста
-	userTest, err := h.userService.GetUserTestByID(ctx, userTestID)
-	if err != nil {
+	case errors.As(err, &cooldownErr):
 		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при получении информации о тесте: %v", err),
+			Text:      fmt.Sprintf("Повторная попытка будет доступна %s.", cooldownErr.AvailableAt.In(h.location).Format("02.01.2006 в 15:04")),
+			ShowAlert: true,
 		})
-	}
-
-	// Получаем сообщение о начале теста
-	startTestMessage, err := h.messageService.GetMessageByKey(ctx, "start_test_message")
-	if err != nil {
+	case errors.Is(err, testService.ErrAttemptsExhausted):
 		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при получении сообщения: %v", err),
+			Text:      "Вы использовали все попытки прохождения этого теста.",
+			ShowAlert: true,
 		})
-	}
-
-	// Получаем пользователя, который назначил тест
-	assignedByUser, err := h.userService.GetUserByID(ctx, userTest.AssignedBy)
-	if err != nil {
+	case errors.Is(err, testService.ErrAssignmentNotAvailable):
+		return c.Respond(&telebot.CallbackResponse{
+			Text: "Этот тест уже начат или больше недоступен.",
+		})
+	case err != nil:
 		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при получении информации о назначившем пользователе: %v", err),
+			Text: fmt.Sprintf("Ошибка при начале теста: %v", err),
 		})
 	}
 
-	// Отправляем сообщение о начале теста пользователю assigned_by
-	_, err = h.bot.Send(&telebot.User{ID: *assignedByUser.TelegramID}, fmt.Sprintf(startTestMessage, username, test.TestName), &telebot.SendOptions{
-		ParseMode: telebot.ModeMarkdown,
-	})
+	// Попытка уже начата и расходует лимит max_attempts. Если подготовить ее не удалось (не хватило вопросов,
+	// не отправилось сообщение), попытка отменяется, чтобы кандидат мог начать тест заново
+	var timerMessage *telebot.Message
+	abort := func(text string) error {
+		if err := h.testService.AbortAttempt(ctx, userTestID); err != nil {
+			log.Printf("Failed to abort test attempt %d: %v", userTestID, err)
+		}
+		if timerMessage != nil {
+			if err := h.bot.Delete(timerMessage); err != nil {
+				log.Printf("Failed to delete timer message: %v", err)
+			}
+		}
+		return c.Respond(&telebot.CallbackResponse{Text: text})
+	}
+
+	// Получаем информацию о назначении теста
+	userTest, err := h.userService.GetUserTestByID(ctx, userTestID)
 	if err != nil {
-		log.Printf("Failed to send message to assigned_by user: %v", err)
-		// Не прерываем выполнение, так как это не критично для начала теста
+		return abort(fmt.Sprintf("Ошибка при получении информации о тесте: %v", err))
+	}
+	test, err := h.testService.GetTestByID(ctx, userTest.TestID)
+	if err != nil {
+		return abort(fmt.Sprintf("Ошибка при получении информации о тесте: %v", err))
 	}
 
 	// Отправляем сообщение с таймером пользователю, который начал тест
-	timerMessage, err := h.bot.Send(c.Sender(), "Тест формируется...", &telebot.SendOptions{
+	timerMessage, err = h.bot.Send(c.Sender(), "Тест формируется...", &telebot.SendOptions{
 		ParseMode: telebot.ModeMarkdown,
 	})
 	if err != nil {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при отправке таймера: %v", err),
-		})
+		return abort(fmt.Sprintf("Ошибка при отправке таймера: %v", err))
 	}
 
 	// Сохраняем ID сообщения таймера в таблицу user_tests
 	err = h.testService.SaveTimerMessageID(ctx, userTestID, timerMessage.ID)
 	if err != nil {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при сохранении ID таймера: %v", err),
-		})
+		return abort(fmt.Sprintf("Ошибка при сохранении ID таймера: %v", err))
 	}
 
-	// Получаем вопросы теста
-	questions, err := h.testService.GetQuestionsByTestID(ctx, test.ID)
+	// Резервируем вопросы: одновременно проходящие тест кандидаты получают разные наборы
+	selectedQuestions, err := h.testService.ReserveQuestions(ctx, userTestID, test)
 	if err != nil {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при получении вопросов: %v", err),
-		})
+		if errors.Is(err, testRepository.ErrNotEnoughQuestions) {
+			return abort("Недостаточно вопросов в тесте для его прохождения.")
+		}
+		return abort(fmt.Sprintf("Ошибка при выборе вопросов: %v", err))
 	}
 
-	// Фильтруем только вопросы типа "single"
-	var singleQuestions []model.Question
-	for _, q := range questions {
-		if q.AnswerType == "single" {
-			singleQuestions = append(singleQuestions, q)
-		}
+	// Сохраняем начальное состояние теста в базе
+	err = h.testService.UpdateUserTestState(ctx, userTestID, 0, 0) // current_question_index = 0, correct_answers_count = 0
+	if err != nil {
+		return abort(fmt.Sprintf("Ошибка при обновлении состояния теста: %v", err))
 	}
 
-	if len(singleQuestions) == 0 {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: "В тесте нет вопросов с одним правильным ответом.",
-		})
+	// Обновляем сообщение таймера перед отправкой первого вопроса
+	currentQuestionIndex := 0
+	totalQuestions := test.QuestionCount // Используем question_count из теста
+	_, err = h.bot.Edit(timerMessage, timer.FormatTimer(time.Until(userTest.TimerDeadline), currentQuestionIndex, totalQuestions), &telebot.SendOptions{
+		ParseMode: telebot.ModeMarkdown,
+	})
+	if err != nil {
+		log.Printf("Failed to update timer message: %v", err)
 	}
 
-	// Проверяем, что вопросов достаточно для question_count
-	if len(singleQuestions) < test.QuestionCount {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Недостаточно вопросов в тесте: доступно %d, требуется %d", len(singleQuestions), test.QuestionCount),
+	// Передаем таймер планировщику, который обновляет сообщение и завершит тест по истечении времени
+	h.timerUpdater.Schedule(model.ActiveTimer{
+		UserTestID:     userTestID,
+		TelegramID:     userID,
+		MessageID:      timerMessage.ID,
+		Deadline:       userTest.TimerDeadline,
+		TotalQuestions: totalQuestions,
+	})
+
+	// Отправляем первый вопрос с порядковым номером, в режиме свободной навигации - с кнопками перехода между вопросами
+	currentQuestion := selectedQuestions[0]
+	if test.FreeNavigation {
+		// Ограничение времени на вопрос в свободной навигации не действует, остается только общее время теста
+		if slices.ContainsFunc(selectedQuestions, func(q model.Question) bool { return q.TimeLimitSeconds != nil }) {
+			log.Printf("Test %d uses free navigation, per-question time limits are ignored", test.ID)
+		}
+		err = h.questionSender.SendNavigable(c.Sender(), userTestID, currentQuestion, question_sender.Navigation{
+			Index: currentQuestionIndex,
+			Total: len(selectedQuestions),
 		})
+	} else {
+		err = h.sendFirstQuestion(ctx, c.Sender(), userTestID, currentQuestion)
+	}
+	if err != nil {
+		return abort(fmt.Sprintf("Ошибка при отправке вопроса: %v", err))
 	}
 
-	// Выбираем случайные question_count вопросов
-	rand.Shuffle(len(singleQuestions), func(i, j int) {
-		singleQuestions[i], singleQuestions[j] = singleQuestions[j], singleQuestions[i]
+	// Уведомляем назначившего тест HR только после того, как кандидат получил первый вопрос
+	h.notifyAssigner(ctx, userTest.AssignedBy, username, test.TestName)
+
+	return c.Respond(&telebot.CallbackResponse{
+		Text: "Тест успешно начат!",
 	})
-	selectedQuestions := singleQuestions[:test.QuestionCount]
+}
 
-	// Сохраняем ID выбранных вопросов
-	var questionIDs []int
-	for _, q := range selectedQuestions {
-		questionIDs = append(questionIDs, q.ID)
-	}
-	err = h.testService.SaveSelectedQuestions(ctx, userTestID, questionIDs)
+// notifyAssigner отправляет назначившему тест HR сообщение о начале теста. Ошибки не прерывают начатый тест
+func (h *StartTestHandler) notifyAssigner(ctx context.Context, assignedBy int, username string, testName string) {
+	startTestMessage, err := h.messageService.GetMessageByKey(ctx, "start_test_message")
 	if err != nil {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при сохранении выбранных вопросов: %v", err),
-		})
+		log.Printf("Failed to get start test message: %v", err)
+		return
 	}
 
-	// Сохраняем начальное состояние теста в базе
-	err = h.testService.UpdateUserTestState(ctx, userTestID, 0, 0) // current_question_index = 0, correct_answers_count = 0
+	assignedByUser, err := h.userService.GetUserByID(ctx, assignedBy)
 	if err != nil {
-		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при обновлении состояния теста: %v", err),
-		})
+		log.Printf("Failed to get assigned_by user: %v", err)
+		return
 	}
 
-	// Обновляем сообщение таймера перед отправкой первого вопроса
-	currentQuestionIndex := 0
-	totalQuestions := test.QuestionCount // Используем question_count из теста
-	timeLeft := time.Until(userTest.TimerDeadline)
-	minutes := int(timeLeft.Minutes())
-	seconds := int(timeLeft.Seconds()) % 60
-	timerText := fmt.Sprintf(
-		"⏰ Тест начался! Оставшееся время: %02d:%02d, Вопрос %d/%d",
-		minutes, seconds, currentQuestionIndex+1, totalQuestions,
-	)
-
-	_, err = h.bot.Edit(timerMessage, timerText, &telebot.SendOptions{
+	_, err = h.bot.Send(&telebot.User{ID: *assignedByUser.TelegramID}, fmt.Sprintf(startTestMessage, username, testName), &telebot.SendOptions{
 		ParseMode: telebot.ModeMarkdown,
 	})
 	if err != nil {
-		log.Printf("Failed to update timer message: %w", err)
+		log.Printf("Failed to send message to assigned_by user: %v", err)
 	}
+}
 
-	// Запускаем горутину для обновления таймера с контекстом
-	go func() {
-		defer cancel()
-		h.timerUpdater.UpdateTimer(ctx, userID, timerMessage.ID, userTest.TimerDeadline, userTestID, totalQuestions)
-	}()
+// sendFirstQuestion отправляет первый вопрос линейного теста и запоминает сообщение с ним,
+// чтобы планировщик таймеров обновлял в нем обратный отсчет, если у вопроса есть ограничение времени
+func (h *StartTestHandler) sendFirstQuestion(ctx context.Context, recipient *telebot.User, userTestID int, question model.Question) error {
+	message, err := h.questionSender.Send(recipient, userTestID, question, 1)
+	if err != nil {
+		return err
+	}
 
-	// Отправляем первый вопрос с порядковым номером
-	currentQuestion := selectedQuestions[0]
-	err = h.sendQuestion(c.Sender(), currentQuestion, currentQuestionIndex+1)
+	err = h.testService.SaveQuestionMessageID(ctx, userTestID, message.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save question message ID: %w", err)
+	}
+
+	if question.TimeLimitSeconds != nil {
+		h.timerUpdater.Wake(userTestID)
+	}
+	return nil
+}
+
+// sendChoice предлагает кандидату выбрать, какое из назначений начать
+func (h *StartTestHandler) sendChoice(c telebot.Context, assignments []model.Assignment) error {
+	ctx := context.Background()
+	message, err := h.messageService.GetMessageByKey(ctx, "choose_test")
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{
+			Text: fmt.Sprintf("Ошибка при получении сообщения: %v", err),
+		})
+	}
+	buttons, err := h.messageService.GetButtons(ctx)
 	if err != nil {
 		return c.Respond(&telebot.CallbackResponse{
-			Text: fmt.Sprintf("Ошибка при отправке вопроса: %v", err),
+			Text: fmt.Sprintf("Ошибка при получении кнопок: %v", err),
 		})
 	}
 
-	return c.Respond(&telebot.CallbackResponse{
-		Text: "Тест успешно начат!",
+	if err := c.Respond(); err != nil {
+		log.Printf("Failed to respond to callback: %v", err)
+	}
+	return c.Send(fmt.Sprintf(message, AssignmentList(assignments, h.location)), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+		ReplyMarkup: &telebot.ReplyMarkup{
+			InlineKeyboard: StartButtons(buttons[model.StartTestKey], assignments),
+		},
 	})
 }
 
+// AssignmentList список назначенных тестов для сообщения кандидату (HTML), даты выводятся в поясе location
+func AssignmentList(assignments []model.Assignment, location *time.Location) string {
+	var list strings.Builder
+	for _, assignment := range assignments {
+		fmt.Fprintf(&list, "• <b>%s</b> - %d мин., вопросов: %d", html.EscapeString(assignment.TestName), assignment.Duration, assignment.QuestionCount)
+		if assignment.Deadline != nil {
+			fmt.Fprintf(&list, ", начать до %s", assignment.Deadline.In(location).Format(dateTimeLayout))
+		}
+		if window := FormatAvailabilityWindow(assignment.AvailabilityWindow, location); window != "" {
+			fmt.Fprintf(&list, ", можно начать %s", window)
+		}
+		list.WriteString("\n")
+	}
+	return list.String()
+}
+
+// FormatAvailabilityWindow описывает окно доступности в поясе location, например
+// "16.10.2026 с 10:00 до 18:00 (MSK)". Для окна без границ возвращает пустую строку
+func FormatAvailabilityWindow(window model.AvailabilityWindow, location *time.Location) string {
+	from, until := window.AvailableFrom, window.AvailableUntil
+	switch {
+	case from != nil && until != nil:
+		start, end := from.In(location), until.In(location)
+		if start.Format(dateLayout) == end.Format(dateLayout) {
+			return fmt.Sprintf("%s с %s до %s (%s)", start.Format(dateLayout), start.Format(timeLayout), end.Format(timeLayout), start.Format("MST"))
+		}
+		return fmt.Sprintf("с %s до %s (%s)", start.Format(dateTimeLayout), end.Format(dateTimeLayout), start.Format("MST"))
+	case from != nil:
+		start := from.In(location)
+		return fmt.Sprintf("с %s (%s)", start.Format(dateTimeLayout), start.Format("MST"))
+	case until != nil:
+		end := until.In(location)
+		return fmt.Sprintf("до %s (%s)", end.Format(dateTimeLayout), end.Format("MST"))
+	default:
+		return ""
+	}
+}
+
+// StartButtons кнопки начала теста, по одной на назначение. Callback содержит ID назначения (user_test_id).
+// Если назначение одно, на кнопке только текст startText, иначе к нему добавляется название теста
+func StartButtons(startText string, assignments []model.Assignment) [][]telebot.InlineButton {
+	var keyboard [][]telebot.InlineButton
+	for _, assignment := range assignments {
+		text := startText
+		if len(assignments) > 1 {
+			text = fmt.Sprintf("%s «%s»", startText, assignment.TestName)
+		}
+		keyboard = append(keyboard, []telebot.InlineButton{{
+			Text:   text,
+			Unique: model.StartTestKey,
+			Data:   strconv.Itoa(assignment.UserTestID),
+		}})
+	}
+	return keyboard
+}
+
 // GetHandlerFunc возвращает обработчик в формате telebot.HandlerFunc
 func (h *StartTestHandler) GetHandlerFunc() telebot.HandlerFunc {
 	return func(c telebot.Context) error {
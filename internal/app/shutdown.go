@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/infra/config"
+	"gopkg.in/telebot.v4"
+	"log"
+	"time"
+)
+
+// handlersPollInterval период проверки завершения обработчиков Telegram при остановке
+const handlersPollInterval = 50 * time.Millisecond
+
+// ShutdownTimeout возвращает дедлайн корректной остановки приложения из конфигурации
+func (app *App) ShutdownTimeout() time.Duration {
+	if timeout := app.config.Server.ShutdownTimeout; timeout > 0 {
+		return timeout
+	}
+	return config.DefaultShutdownTimeout
+}
+
+// trackHandlers middleware учета выполняющихся обработчиков Telegram.
+// telebot запускает обработчики в отдельных горутинах, поэтому при остановке их нужно дождаться отдельно.
+func (app *App) trackHandlers(next telebot.HandlerFunc) telebot.HandlerFunc {
+	return func(c telebot.Context) error {
+		app.activeHandlers.Add(1)
+		defer app.activeHandlers.Add(-1)
+		return next(c)
+	}
+}
+
+// waitHandlers ожидает завершения выполняющихся обработчиков Telegram
+func (app *App) waitHandlers(ctx context.Context) error {
+	ticker := time.NewTicker(handlersPollInterval)
+	defer ticker.Stop()
+
+	for app.activeHandlers.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d telegram handlers still running: %w", app.activeHandlers.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// stopBot останавливает получение обновлений Telegram
+func (app *App) stopBot(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		app.bot.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop telegram bot: %w", ctx.Err())
+	}
+}
+
+// stopTimers останавливает планировщик таймеров. Дедлайны и ID сообщений таймеров хранятся в user_tests,
+// поэтому после перезапуска планировщик восстановит их и продолжит отсчет.
+func (app *App) stopTimers(ctx context.Context) error {
+	app.cancelTimers()
+
+	select {
+	case <-app.timersDone:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop timer scheduler: %w", ctx.Err())
+	}
+}
+
+// Shutdown останавливает приложение: HTTP сервер (в том числе прием вебхуков), получение обновлений Telegram,
+// дожидается выполняющихся обработчиков, останавливает таймеры и закрывает пул соединений с базой данных.
+// Все этапы должны уложиться в дедлайн ctx.
+func (app *App) Shutdown(ctx context.Context) error {
+	app.lifecycle.Lock()
+	app.stopping = true
+	app.lifecycle.Unlock()
+
+	var errs []error
+
+	if app.server != nil {
+		if err := app.server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown HTTP server: %w", err))
+		}
+	}
+
+	if app.bot != nil {
+		if err := app.stopBot(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		if err := app.waitHandlers(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if app.timerUpdater != nil {
+		if err := app.stopTimers(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	app.db.Close()
+
+	log.Printf("Application stopped")
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	httpError "github.com/IT-Nick/pkg/http"
+	"gopkg.in/telebot.v4"
+	"net/http"
+)
+
+// webhookPoller принимает обновления Telegram на общем HTTP сервере приложения.
+// Вебхук регистрируется при запуске бота (register), чтобы ошибка регистрации останавливала запуск,
+// а канал обновлений бота подключается до монтирования маршрута, поэтому обработчик не пишет в nil канал.
+type webhookPoller struct {
+	webhook *telebot.Webhook
+	updates chan<- telebot.Update // Канал обновлений бота, задается в register до запуска HTTP сервера
+	stopped chan struct{}         // Закрывается после остановки бота, новые обновления не принимаются
+}
+
+func newWebhookPoller(webhook *telebot.Webhook) *webhookPoller {
+	return &webhookPoller{
+		webhook: webhook,
+		stopped: make(chan struct{}),
+	}
+}
+
+// register регистрирует вебхук в Telegram и подключает канал обновлений бота
+func (p *webhookPoller) register(b *telebot.Bot) error {
+	if err := b.SetWebhook(p.webhook); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	p.updates = b.Updates
+	return nil
+}
+
+// Poll ожидает остановки бота: обновления передаются в канал бота из ServeHTTP
+func (p *webhookPoller) Poll(_ *telebot.Bot, _ chan telebot.Update, stop chan struct{}) {
+	<-stop
+	close(p.stopped)
+}
+
+func (p *webhookPoller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var update telebot.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		httpError.ErrorResponse(w, http.StatusBadRequest, "invalid update")
+		return
+	}
+
+	select {
+	case p.updates <- update:
+	case <-p.stopped:
+		httpError.ErrorResponse(w, http.StatusServiceUnavailable, "bot is stopped")
+	case <-r.Context().Done():
+	}
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuthRepository хранит API ключи в базе данных PostgreSQL
+type AuthRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAuthRepository создает новый экземпляр AuthRepository
+func NewAuthRepository(db *pgxpool.Pool) *AuthRepository {
+	return &AuthRepository{db: db}
+}
+
+// RotateAPIKey отзывает действующие API ключи пользователя и сохраняет хеш нового ключа в одной транзакции
+func (r *AuthRepository) RotateAPIKey(ctx context.Context, userID int, keyHash string, name string) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        UPDATE api_keys
+        SET revoked_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND revoked_at IS NULL
+    `, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke api keys: %w", err)
+	}
+
+	var keyID int
+	err = tx.QueryRow(ctx,
+		"INSERT INTO api_keys (user_id, key_hash, name) VALUES ($1, $2, $3) RETURNING id",
+		userID, keyHash, name).Scan(&keyID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return keyID, nil
+}
+
+// UseAPIKey находит действующий API ключ по хешу, отмечает его использование и возвращает ID владельца.
+// Если ключ не найден или отозван, возвращает 0.
+func (r *AuthRepository) UseAPIKey(ctx context.Context, keyHash string) (int, error) {
+	query := `
+        UPDATE api_keys
+        SET last_used_at = CURRENT_TIMESTAMP
+        WHERE key_hash = $1 AND revoked_at IS NULL
+        RETURNING user_id
+    `
+	var userID int
+	err := r.db.QueryRow(ctx, query, keyHash).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to use api key: %w", err)
+	}
+	return userID, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/auth/repository"
+	"github.com/IT-Nick/internal/domain/model"
+	usersRepo "github.com/IT-Nick/internal/domain/users/repository"
+	"strings"
+)
+
+// apiKeyPrefix префикс API ключей, позволяет отличить ключ бота от других секретов
+const apiKeyPrefix = "hrb_"
+
+// AuthService содержит логику выпуска и проверки API ключей и сессий HR панели
+type AuthService struct {
+	authRepo *repository.AuthRepository
+	userRepo *usersRepo.UserRepository
+	session  SessionSettings
+}
+
+// NewAuthService создает новый экземпляр AuthService
+func NewAuthService(authRepo *repository.AuthRepository, userRepo *usersRepo.UserRepository, session SessionSettings) *AuthService {
+	return &AuthService{authRepo: authRepo, userRepo: userRepo, session: session}
+}
+
+// hashAPIKey возвращает SHA-256 хеш ключа. Ключи случайные и длинные, поэтому медленный хеш не требуется.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueAPIKey выпускает новый API ключ пользователю, отзывая его предыдущие ключи.
+// Ключ возвращается один раз, в базе хранится только его хеш.
+func (s *AuthService) IssueAPIKey(ctx context.Context, username string, name string) (string, error) {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return "", fmt.Errorf("user %s not found", username)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	if _, err := s.authRepo.RotateAPIKey(ctx, user.ID, hashAPIKey(key), name); err != nil {
+		return "", fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey возвращает владельца API ключа. Для неизвестного или отозванного ключа возвращает nil.
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, key string) (*model.User, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil
+	}
+
+	userID, err := s.authRepo.UseAPIKey(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check api key: %w", err)
+	}
+	if userID == 0 {
+		return nil, nil
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key owner: %w", err)
+	}
+	return user, nil
+}
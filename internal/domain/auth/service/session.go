@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"github.com/jackc/pgx/v5"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибки входа через Telegram Login Widget
+var (
+	ErrInvalidLogin = errors.New("invalid telegram login data")
+	ErrLoginExpired = errors.New("telegram login data expired")
+	ErrUnknownUser  = errors.New("user not registered in bot")
+)
+
+// SessionSettings параметры входа через Telegram Login Widget и подписанных сессий
+type SessionSettings struct {
+	BotToken    string        // Токен бота, ключ проверки подписи виджета
+	Secret      string        // Ключ подписи сессий; если не задан, выводится из токена бота
+	TTL         time.Duration // Время жизни сессии
+	LoginMaxAge time.Duration // Максимальный возраст данных виджета (auth_date)
+}
+
+// sessionHeader заголовок JWT сессии, подпись HMAC-SHA256
+var sessionHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// sessionClaims полезная нагрузка JWT сессии
+type sessionClaims struct {
+	Subject    string `json:"sub"` // ID пользователя в таблице users
+	TelegramID int64  `json:"tid"`
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+// sessionKey возвращает ключ подписи сессий
+func (s *AuthService) sessionKey() []byte {
+	if s.session.Secret != "" {
+		return []byte(s.session.Secret)
+	}
+	mac := hmac.New(sha256.New, []byte("hr-bot-session"))
+	mac.Write([]byte(s.session.BotToken))
+	return mac.Sum(nil)
+}
+
+// VerifyTelegramLogin проверяет подпись данных Telegram Login Widget и возвращает telegram ID пользователя.
+// Подпись - HMAC-SHA256 от отсортированных пар key=value, ключ - SHA-256 от токена бота.
+func (s *AuthService) VerifyTelegramLogin(fields map[string]string) (int64, error) {
+	hash, ok := fields["hash"]
+	if !ok || hash == "" {
+		return 0, ErrInvalidLogin
+	}
+
+	pairs := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if key != "hash" {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	sort.Strings(pairs)
+
+	secret := sha256.Sum256([]byte(s.session.BotToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(hash))) {
+		return 0, ErrInvalidLogin
+	}
+
+	authDate, err := strconv.ParseInt(fields["auth_date"], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidLogin
+	}
+	if time.Since(time.Unix(authDate, 0)) > s.session.LoginMaxAge {
+		return 0, ErrLoginExpired
+	}
+
+	telegramID, err := strconv.ParseInt(fields["id"], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidLogin
+	}
+	return telegramID, nil
+}
+
+// LoginWithTelegram проверяет данные виджета, находит пользователя по users.telegram_id и выпускает сессию
+func (s *AuthService) LoginWithTelegram(ctx context.Context, fields map[string]string) (*model.User, string, time.Time, error) {
+	telegramID, err := s.VerifyTelegramLogin(fields)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	user, err := s.userRepo.GetUserByTelegramID(ctx, telegramID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", time.Time{}, ErrUnknownUser
+		}
+		return nil, "", time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, expiresAt, err := s.IssueSession(user)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	return user, token, expiresAt, nil
+}
+
+// IssueSession выпускает подписанный JWT сессии пользователя
+func (s *AuthService) IssueSession(user *model.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.session.TTL)
+
+	claims := sessionClaims{
+		Subject:   strconv.Itoa(user.ID),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	}
+	if user.TelegramID != nil {
+		claims.TelegramID = *user.TelegramID
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	unsigned := sessionHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + s.sign(unsigned), expiresAt, nil
+}
+
+// sign возвращает подпись части JWT
+func (s *AuthService) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, s.sessionKey())
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// AuthenticateSession проверяет подпись и срок действия JWT сессии и возвращает пользователя.
+// Для недействительной сессии возвращает nil.
+func (s *AuthService) AuthenticateSession(ctx context.Context, token string) (*model.User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != sessionHeader {
+		return nil, nil
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return nil, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil
+	}
+	var claims sessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, nil
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, nil
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get session user: %w", err)
+	}
+	return user, nil
+}
+
+// Authenticate возвращает пользователя по API ключу или JWT сессии. Для недействительного токена возвращает nil.
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*model.User, error) {
+	if strings.HasPrefix(token, apiKeyPrefix) {
+		return s.AuthenticateAPIKey(ctx, token)
+	}
+	return s.AuthenticateSession(ctx, token)
+}
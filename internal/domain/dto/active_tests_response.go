@@ -26,12 +26,19 @@ type QuestionInfoActive struct {
 	QuestionText string   `json:"question_text"`
 	AnswerType   string   `json:"answer_type"`
 	TestOptions  []string `json:"test_options"`
+	OptionOrder  []int    `json:"option_order,omitempty"` // Порядок, в котором кандидат видит варианты (индексы test_options)
 }
 
 type AnswerInfo struct {
-	QuestionID   int    `json:"question_id"`
-	QuestionText string `json:"question_text"`
-	UserAnswer   string `json:"user_answer"`
-	IsCorrect    bool   `json:"is_correct"`
-	AnsweredAt   string `json:"answered_at"`
+	QuestionID   int      `json:"question_id"`
+	QuestionText string   `json:"question_text"`
+	AnswerType   string   `json:"answer_type"`
+	UserAnswer   string   `json:"user_answer"`
+	UserAnswers  []string `json:"user_answers,omitempty"` // Выбранные варианты для типа "multiple"
+	IsCorrect    bool     `json:"is_correct"`
+	ReviewStatus string   `json:"review_status,omitempty"` // auto, pending, reviewed
+	AnsweredAt   string   `json:"answered_at"`
+
+	ElapsedSeconds *int `json:"elapsed_seconds,omitempty"` // Сколько времени кандидат отвечал на вопрос
+	TimedOut       bool `json:"timed_out,omitempty"`       // Время на ответ истекло, ответ не дан
 }
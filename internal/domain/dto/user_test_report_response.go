@@ -10,6 +10,9 @@ type UserTestReportResponse struct {
 
 type TestHistory struct {
 	UserTestID     int            `json:"user_test_id"`
+	AssignmentID   int            `json:"assignment_id"`  // Назначение, к которому относится попытка (для первой попытки совпадает с user_test_id)
+	AttemptNumber  int            `json:"attempt_number"` // Номер попытки в назначении, начиная с 1
+	MaxAttempts    int            `json:"max_attempts"`
 	TestID         int            `json:"test_id"`
 	TestName       string         `json:"test_name"`
 	TestType       string         `json:"test_type"`
@@ -26,12 +29,21 @@ type TestHistory struct {
 }
 
 type QuestionInfo struct {
-	QuestionID    int      `json:"question_id"`
-	QuestionText  string   `json:"question_text"`
-	AnswerType    string   `json:"answer_type"`
-	CorrectAnswer string   `json:"correct_answer,omitempty"`
-	TestOptions   []string `json:"test_options,omitempty"`
-	UserAnswer    string   `json:"user_answer"`
-	IsCorrect     bool     `json:"is_correct"`
-	AnsweredAt    string   `json:"answered_at"`
+	QuestionID     int      `json:"question_id"`
+	QuestionText   string   `json:"question_text"`
+	AnswerType     string   `json:"answer_type"`
+	CorrectAnswer  string   `json:"correct_answer,omitempty"`
+	CorrectAnswers []string `json:"correct_answers,omitempty"` // Множество правильных ответов для типа "multiple"
+	TestOptions    []string `json:"test_options,omitempty"`
+	OptionOrder    []int    `json:"option_order,omitempty"` // Порядок, в котором кандидат видел варианты (индексы test_options)
+	UserAnswer     string   `json:"user_answer"`
+	UserAnswers    []string `json:"user_answers,omitempty"` // Выбранные варианты для типа "multiple"
+	IsCorrect      bool     `json:"is_correct"`
+	ReviewStatus   string   `json:"review_status,omitempty"` // auto, pending, reviewed
+	ReviewComment  string   `json:"review_comment,omitempty"`
+	AnsweredAt     string   `json:"answered_at"`
+
+	TimeLimitSeconds *int `json:"time_limit_seconds,omitempty"` // Ограничение времени на ответ
+	ElapsedSeconds   *int `json:"elapsed_seconds,omitempty"`    // Сколько времени кандидат отвечал на вопрос
+	TimedOut         bool `json:"timed_out,omitempty"`          // Время на ответ истекло, ответ не дан
 }
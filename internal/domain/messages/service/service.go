@@ -31,7 +31,7 @@ func (s *MessageService) GetButtons(ctx context.Context) (map[string]string, err
 	buttons := make(map[string]string)
 
 	// Получаем текст для каждой кнопки из базы данных
-	for _, key := range []string{model.StartTestKey, model.AssignHRKey, model.AssignAdminKey, model.AssignTestKey} {
+	for _, key := range []string{model.StartTestKey, model.AssignHRKey, model.AssignAdminKey, model.AssignTestKey, model.ReviewAnswersKey, model.GenerateQRKey} {
 		text, err := s.messageRepo.GetMessageByKey(ctx, key)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get button text for key %s: %w", key, err)
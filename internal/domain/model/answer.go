@@ -2,13 +2,35 @@ package model
 
 import "time"
 
+// Статусы проверки ответа (answers.review_status)
+const (
+	ReviewStatusAuto     = "auto"     // Ответ проверен автоматически
+	ReviewStatusPending  = "pending"  // Ответ ожидает ручной проверки
+	ReviewStatusReviewed = "reviewed" // Ответ проверен менеджером
+)
+
 // Answer представляет ответ пользователя на вопрос теста
 type Answer struct {
-	ID         int       `json:"id"`
-	UserTestID int       `json:"user_test_id"`
-	QuestionID int       `json:"question_id"`
-	UserAnswer string    `json:"user_answer"`
-	IsCorrect  bool      `json:"is_correct"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID             int        `json:"id"`
+	UserTestID     int        `json:"user_test_id"`
+	QuestionID     int        `json:"question_id"`
+	UserAnswer     string     `json:"user_answer"`
+	IsCorrect      bool       `json:"is_correct"`
+	ReviewStatus   string     `json:"review_status"`
+	ReviewComment  *string    `json:"review_comment,omitempty"`
+	ReviewedBy     *int       `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	ElapsedSeconds *int       `json:"elapsed_seconds,omitempty"` // Сколько времени кандидат отвечал на вопрос
+	TimedOut       bool       `json:"timed_out,omitempty"`       // Время на ответ истекло, ответ не дан
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// PendingReview ответ, ожидающий ручной проверки, с данными для проверяющего
+type PendingReview struct {
+	Answer        Answer `json:"answer"`
+	QuestionText  string `json:"question_text"`
+	CorrectAnswer string `json:"correct_answer"`
+	Username      string `json:"username"`
+	TestName      string `json:"test_name"`
 }
@@ -1,10 +1,124 @@
 package model
 
+import (
+	"sync"
+	"time"
+)
+
 // Константы для кнопок. Привязаны к названиям обработчиков.
 // Не следует добавлять/изменять константы без изменения логики в обработчике start
 const (
-	StartTestKey   = "start_test"
-	AssignHRKey    = "assign_hr"
-	AssignAdminKey = "assign_admin"
-	AssignTestKey  = "assign_test"
+	StartTestKey     = "start_test"
+	AssignHRKey      = "assign_hr"
+	AssignAdminKey   = "assign_admin"
+	AssignTestKey    = "assign_test"
+	ReviewAnswersKey = "review_answers"
+	GenerateQRKey    = "generate_qr"
+)
+
+// Назначение списка выбора теста: список с пагинацией общий для назначения теста и генерации QR-кода
+const (
+	TestPickerAssign = "assign"
+	TestPickerQR     = "qr"
 )
+
+// TestPickerPrompts заголовки списка выбора теста по его назначению
+var TestPickerPrompts = map[string]string{
+	TestPickerAssign: "Какой тест назначить кандидату?",
+	TestPickerQR:     "Для какого теста сгенерировать QR-код?",
+}
+
+// TestPickerState назначение списка выбора теста по telegram ID пользователя.
+// Состояние общее для обработчиков списка, назначения теста и QR-кода, поэтому защищено одним мьютексом.
+type TestPickerState struct {
+	mutex    sync.Mutex
+	purposes map[int64]string
+}
+
+// NewTestPickerState создает пустое состояние списка выбора теста
+func NewTestPickerState() *TestPickerState {
+	return &TestPickerState{purposes: make(map[int64]string)}
+}
+
+// Get возвращает назначение списка (TestPicker*), пустую строку, если оно не задано
+func (s *TestPickerState) Get(telegramID int64) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.purposes[telegramID]
+}
+
+// Set задает назначение списка
+func (s *TestPickerState) Set(telegramID int64, purpose string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.purposes[telegramID] = purpose
+}
+
+// Delete сбрасывает назначение списка
+func (s *TestPickerState) Delete(telegramID int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.purposes, telegramID)
+}
+
+// AssignTestState тест и срок его начала, выбранные HR для текущего назначения, по telegram ID HR.
+// Выбор теста, срока и ввод кандидатов обрабатываются в разных горутинах telebot, поэтому состояние защищено мьютексом.
+type AssignTestState struct {
+	mutex     sync.Mutex
+	tests     map[int64]int
+	deadlines map[int64]time.Time
+}
+
+// NewAssignTestState создает пустое состояние назначения теста
+func NewAssignTestState() *AssignTestState {
+	return &AssignTestState{
+		tests:     make(map[int64]int),
+		deadlines: make(map[int64]time.Time),
+	}
+}
+
+// Select запоминает выбранный тест; срок предыдущего назначения не переносится на новое
+func (s *AssignTestState) Select(telegramID int64, testID int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tests[telegramID] = testID
+	delete(s.deadlines, telegramID)
+}
+
+// Test возвращает выбранный тест и признак того, что тест выбран
+func (s *AssignTestState) Test(telegramID int64) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	testID, ok := s.tests[telegramID]
+	return testID, ok
+}
+
+// SetDeadline задает срок начала теста, nil - без срока
+func (s *AssignTestState) SetDeadline(telegramID int64, deadline *time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if deadline == nil {
+		delete(s.deadlines, telegramID)
+		return
+	}
+	s.deadlines[telegramID] = *deadline
+}
+
+// Deadline возвращает выбранный срок начала теста или nil
+func (s *AssignTestState) Deadline(telegramID int64) *time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	deadline, ok := s.deadlines[telegramID]
+	if !ok {
+		return nil
+	}
+	return &deadline
+}
+
+// Delete сбрасывает выбранный тест и срок после завершения назначения
+func (s *AssignTestState) Delete(telegramID int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.tests, telegramID)
+	delete(s.deadlines, telegramID)
+}
@@ -2,14 +2,36 @@ package model
 
 import "time"
 
+// Типы ответов на вопрос теста
+const (
+	AnswerTypeSingle   = "single"
+	AnswerTypeMultiple = "multiple"
+	AnswerTypeText     = "text"
+)
+
+// Способы автоматической проверки свободного ответа
+const (
+	MatcherExact           = "exact"
+	MatcherCaseInsensitive = "case_insensitive"
+	MatcherRegex           = "regex"
+	MatcherNumeric         = "numeric"
+	MatcherSynonyms        = "synonyms"
+	MatcherManual          = "manual" // Ответ проверяет менеджер вручную
+)
+
 // Question представляет вопрос теста
 type Question struct {
-	ID            int       `json:"id"`
-	TestID        int       `json:"test_id"`
-	QuestionText  string    `json:"question_text"`
-	AnswerType    string    `json:"answer_type"` // "single", "multiple", "text"
-	CorrectAnswer string    `json:"correct_answer"`
-	TestOptions   []string  `json:"test_options"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID               int       `json:"id"`
+	TestID           int       `json:"test_id"`
+	QuestionText     string    `json:"question_text"`
+	AnswerType       string    `json:"answer_type"`    // "single", "multiple", "text"
+	CorrectAnswer    string    `json:"correct_answer"` // Для "multiple" - JSON-массив правильных вариантов
+	TestOptions      []string  `json:"test_options"`
+	AnswerMatcher    string    `json:"answer_matcher,omitempty"`     // Способ проверки ответа для типа "text"
+	AcceptedAnswers  []string  `json:"accepted_answers,omitempty"`   // Синонимы правильного ответа
+	NumericTolerance *float64  `json:"numeric_tolerance,omitempty"`  // Допустимая погрешность числового ответа
+	TimeLimitSeconds *int      `json:"time_limit_seconds,omitempty"` // Время на ответ, nil - ограничено только общим временем теста
+	OptionOrder      []int     `json:"option_order,omitempty"`       // Порядок показа вариантов кандидату (индексы TestOptions), nil - исходный порядок
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -11,3 +11,42 @@ type Permission struct {
 	ID   int    `json:"id"`
 	Name string `json:"permission_name"`
 }
+
+// Названия ролей (roles.role_name)
+const (
+	RoleUser    = "user"
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+)
+
+// Названия прав (permissions.permission_name)
+const (
+	PermissionStartTest   = "start_test"
+	PermissionAssignTest  = "assign_test"
+	PermissionAssignHR    = "assign_hr"
+	PermissionAssignAdmin = "assign_admin"
+	PermissionGenerateQR  = "generate_qr"
+	PermissionViewReports = "view_reports"
+)
+
+// RoleTitle возвращает название роли для сообщений пользователям
+func RoleTitle(roleName string) string {
+	switch roleName {
+	case RoleManager:
+		return "HR"
+	case RoleAdmin:
+		return "ADMIN"
+	case RoleUser:
+		return "кандидат"
+	}
+	return roleName
+}
+
+// PendingPromotion отложенное назначение роли пользователю, который еще не написал /start
+type PendingPromotion struct {
+	ID               int    `json:"id"`
+	TelegramUsername string `json:"telegram_username"`
+	RoleID           int    `json:"role_id"`
+	RoleName         string `json:"role_name"`
+	PromotedBy       int    `json:"promoted_by"`
+}
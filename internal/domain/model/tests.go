@@ -3,11 +3,31 @@ package model
 import "time"
 
 type Test struct {
-	ID            int       `json:"id"`
-	TestName      string    `json:"test_name"`
-	TestType      string    `json:"test_type"`
-	Duration      int       `json:"duration"`
-	QuestionCount int       `json:"question_count"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             int           `json:"id"`
+	TestName       string        `json:"test_name"`
+	TestType       string        `json:"test_type"`
+	Duration       int           `json:"duration"`
+	QuestionCount  int           `json:"question_count"`
+	MaxAttempts    int           `json:"max_attempts,omitempty"`    // Сколько раз кандидат может пройти тест по одному назначению
+	RetakeCooldown time.Duration `json:"retake_cooldown,omitempty"` // Пауза между попытками
+	FreeNavigation bool          `json:"free_navigation,omitempty"` // Кандидат может пропускать вопросы и менять ответы до отправки
+	ShuffleOptions bool          `json:"shuffle_options,omitempty"` // Варианты ответа показываются каждому кандидату в своем порядке
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+}
+
+// TestLink ссылка (QR-код) на прохождение теста, выпущенная HR
+type TestLink struct {
+	ID                int        `json:"id"`
+	TestID            int        `json:"test_id"`
+	Token             string     `json:"token"`
+	CreatedBy         int        `json:"created_by"`
+	CreatedByUsername string     `json:"created_by_username"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	MaxUses           *int       `json:"max_uses,omitempty"`
+	UseCount          int        `json:"use_count"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+
+	AvailabilityWindow // Окно доступности, которое получают назначения по ссылке
 }
@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 type User struct {
 	ID                int       `json:"id"`
@@ -15,3 +18,14 @@ type User struct {
 	CreatedAt         time.Time `json:"created_at"`
 	UpdatedAt         time.Time `json:"updated_at"`
 }
+
+// FullName возвращает ФИО пользователя, пропуская незаполненные части
+func (u *User) FullName() string {
+	var parts []string
+	for _, part := range []*string{u.RealFirstName, u.RealSecondName, u.RealSurname} {
+		if part != nil && *part != "" {
+			parts = append(parts, *part)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -2,6 +2,17 @@ package model
 
 import "time"
 
+// Статусы назначения теста (user_tests.status)
+const (
+	UserTestStatusPending       = "pending"        // Отложенное назначение, кандидат еще не написал /start
+	UserTestStatusAssigned      = "assigned"       // Тест назначен, но не начат
+	UserTestStatusInProgress    = "in_progress"    // Кандидат проходит тест
+	UserTestStatusPendingReview = "pending_review" // Тест пройден, часть ответов ожидает ручной проверки
+	UserTestStatusFinished      = "finished"       // Тест завершен, балл окончательный
+	UserTestStatusExpired       = "expired"        // Кандидат не начал тест до срока (deadline)
+	UserTestStatusCancelled     = "cancelled"      // Назначение отменено HR
+)
+
 type UserTest struct {
 	ID                   int        `json:"id"`
 	UserID               int        `json:"user_id,omitempty"`
@@ -15,6 +26,84 @@ type UserTest struct {
 	StartTime            time.Time  `json:"start_time,omitempty"`
 	EndTime              *time.Time `json:"end_time,omitempty"`
 	Status               *string    `json:"status,omitempty"`
+	AttemptOf            *int       `json:"attempt_of,omitempty"` // Исходное назначение для повторной попытки, nil для первой
+	AttemptNumber        int        `json:"attempt_number"`
 	CreatedAt            time.Time  `json:"created_at"`
 	UpdatedAt            time.Time  `json:"updated_at"`
 }
+
+// ActiveTimer описывает таймер теста в процессе прохождения
+type ActiveTimer struct {
+	UserTestID     int       `json:"user_test_id"`
+	TelegramID     int64     `json:"telegram_id"`
+	MessageID      int       `json:"message_id"` // 0, если сообщение с таймером еще не отправлено
+	Deadline       time.Time `json:"deadline"`
+	TotalQuestions int       `json:"total_questions"`
+}
+
+// AvailabilityWindow интервал, в который тест можно начать. Граница nil не ограничивает интервал
+type AvailabilityWindow struct {
+	AvailableFrom  *time.Time `json:"available_from,omitempty"`
+	AvailableUntil *time.Time `json:"available_until,omitempty"`
+}
+
+// Assignment назначение теста кандидату с данными для списка назначений HR
+type Assignment struct {
+	UserTestID          int        `json:"user_test_id"`
+	TestID              int        `json:"test_id"`
+	TestName            string     `json:"test_name"`
+	Duration            int        `json:"duration"`
+	QuestionCount       int        `json:"question_count"`
+	AssignedBy          int        `json:"assigned_by"`
+	CandidateUsername   string     `json:"candidate_username"`
+	CandidateTelegramID *int64     `json:"candidate_telegram_id,omitempty"` // nil для отложенных назначений
+	Status              string     `json:"status"`
+	Deadline            *time.Time `json:"deadline,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	AvailabilityWindow
+}
+
+// ExpiredAssignment назначение, просроченное фоновой задачей, с данными для уведомлений
+type ExpiredAssignment struct {
+	UserTestID          int       `json:"user_test_id"`
+	TestName            string    `json:"test_name"`
+	CandidateUsername   string    `json:"candidate_username"`
+	CandidateTelegramID *int64    `json:"candidate_telegram_id,omitempty"` // nil для отложенных назначений
+	HRTelegramID        *int64    `json:"hr_telegram_id,omitempty"`
+	Deadline            time.Time `json:"deadline"`
+}
+
+// Reminder напоминание кандидату о назначенном, но не начатом тесте
+type Reminder struct {
+	UserTestID int        `json:"user_test_id"`
+	TelegramID int64      `json:"telegram_id"`
+	TestName   string     `json:"test_name"`
+	Deadline   *time.Time `json:"deadline,omitempty"`
+}
+
+// UserTestProgress текущий вопрос и статус прохождения теста
+type UserTestProgress struct {
+	CurrentQuestionIndex int    `json:"current_question_index"`
+	Status               string `json:"status"`
+
+	// Ограничение времени текущего вопроса, QuestionDeadline равен nil, если у вопроса нет лимита
+	QuestionID        int        `json:"question_id"`
+	QuestionMessageID int        `json:"question_message_id"` // 0, если сообщение с вопросом еще не отправлено
+	QuestionSelection uint64     `json:"question_selection"`  // Отмеченные варианты вопроса с несколькими ответами
+	QuestionDeadline  *time.Time `json:"question_deadline,omitempty"`
+}
+
+// Результаты назначения теста в массовом назначении
+const (
+	BulkAssignAssigned  = "assigned"  // Тест назначен зарегистрированному пользователю
+	BulkAssignPending   = "pending"   // Пользователь еще не писал /start, создано отложенное назначение
+	BulkAssignDuplicate = "duplicate" // Username повторяется в списке или тест уже назначен
+	BulkAssignInvalid   = "invalid"   // Некорректный username
+)
+
+// BulkAssignmentResult результат назначения теста одному пользователю из списка
+type BulkAssignmentResult struct {
+	Username   string `json:"username"`
+	Status     string `json:"status"`
+	UserTestID int    `json:"user_test_id,omitempty"`
+}
@@ -97,6 +97,12 @@ func (s *RoleService) GetRoleBasedKeyboard(ctx context.Context, username string,
 				Unique: "assign_test",
 				Data:   "assign_test",
 			})
+			// Менеджеры, назначающие тесты, проверяют и свободные ответы кандидатов
+			keyboard = append(keyboard, telebot.InlineButton{
+				Text:   buttonsMessages[model.ReviewAnswersKey],
+				Unique: model.ReviewAnswersKey,
+				Data:   model.ReviewAnswersKey,
+			})
 		case "assign_hr":
 			keyboard = append(keyboard, telebot.InlineButton{
 				Text:   buttonsMessages["assign_hr"],
@@ -109,6 +115,12 @@ func (s *RoleService) GetRoleBasedKeyboard(ctx context.Context, username string,
 				Unique: "assign_admin",
 				Data:   "assign_admin",
 			})
+		case model.PermissionGenerateQR:
+			keyboard = append(keyboard, telebot.InlineButton{
+				Text:   buttonsMessages[model.GenerateQRKey],
+				Unique: model.GenerateQRKey,
+				Data:   model.GenerateQRKey,
+			})
 		}
 	}
 
@@ -7,12 +7,57 @@ import (
 	"fmt"
 	"github.com/IT-Nick/internal/domain/model"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log"
 	"time"
 )
 
+// ErrNotEnoughQuestions возвращается, если в тесте меньше вопросов, чем требует question_count
+var ErrNotEnoughQuestions = errors.New("not enough questions in test")
+
+// ErrAnswerAlreadyReviewed возвращается, если ответ уже проверен другим менеджером
+var ErrAnswerAlreadyReviewed = errors.New("answer already reviewed")
+
+// ErrAssignmentNotAvailable возвращается, если назначение нельзя начать: его нет, оно уже начато, отменено или просрочено
+var ErrAssignmentNotAvailable = errors.New("assignment is not available")
+
+// ErrAttemptsExhausted возвращается, если исчерпан лимит попыток прохождения теста
+var ErrAttemptsExhausted = errors.New("test attempts exhausted")
+
+// ErrRetakeCooldown возвращается (в составе RetakeCooldownError), если пауза перед повторной попыткой еще не прошла
+var ErrRetakeCooldown = errors.New("retake cooldown has not passed")
+
+// RetakeCooldownError сообщает, когда станет доступна повторная попытка
+type RetakeCooldownError struct {
+	AvailableAt time.Time
+}
+
+func (e *RetakeCooldownError) Error() string {
+	return fmt.Sprintf("%v: available at %s", ErrRetakeCooldown, e.AvailableAt.Format(time.RFC3339))
+}
+
+func (e *RetakeCooldownError) Unwrap() error {
+	return ErrRetakeCooldown
+}
+
+// ErrOutsideAvailabilityWindow возвращается (в составе AvailabilityWindowError), если тест начинают вне окна доступности
+var ErrOutsideAvailabilityWindow = errors.New("outside of test availability window")
+
+// AvailabilityWindowError сообщает окно доступности, вне которого пытались начать тест
+type AvailabilityWindowError struct {
+	Window model.AvailabilityWindow
+}
+
+func (e *AvailabilityWindowError) Error() string {
+	return ErrOutsideAvailabilityWindow.Error()
+}
+
+func (e *AvailabilityWindowError) Unwrap() error {
+	return ErrOutsideAvailabilityWindow
+}
+
 // TestRepository репозиторий для работы с тестами
 type TestRepository struct {
 	db *pgxpool.Pool
@@ -58,14 +103,29 @@ func (r *TestRepository) GetTotalTestsCount(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// AssignTestToUser назначает тест существующему пользователю
-func (r *TestRepository) AssignTestToUser(ctx context.Context, userID int, testID int, assignedByID int) (int, error) {
+// querier общие методы пула соединений и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// AssignTestToUser назначает тест существующему пользователю. deadline - срок, до которого тест нужно начать, nil - без срока
+func (r *TestRepository) AssignTestToUser(ctx context.Context, userID int, testID int, assignedByID int, deadline *time.Time, window model.AvailabilityWindow) (int, error) {
+	return assignTestToUser(ctx, r.db, userID, testID, assignedByID, deadline, window)
+}
+
+// AssignPendingTest создает отложенное назначение теста. deadline - срок, до которого тест нужно начать, nil - без срока
+func (r *TestRepository) AssignPendingTest(ctx context.Context, telegramUsername string, testID int, assignedByID int, deadline *time.Time, window model.AvailabilityWindow) (int, error) {
+	return assignPendingTest(ctx, r.db, telegramUsername, testID, assignedByID, deadline, window)
+}
+
+func assignTestToUser(ctx context.Context, q querier, userID int, testID int, assignedByID int, deadline *time.Time, window model.AvailabilityWindow) (int, error) {
 	var userTestID int
-	err := r.db.QueryRow(ctx, `
-                INSERT INTO user_tests (user_id, test_id, assigned_by, status, created_at, updated_at) 
-                VALUES ($1, $2, $3, 'assigned', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
+	err := q.QueryRow(ctx, `
+                INSERT INTO user_tests (user_id, test_id, assigned_by, status, deadline, available_from, available_until, created_at, updated_at) 
+                VALUES ($1, $2, $3, 'assigned', $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
                 RETURNING id
-        `, userID, testID, assignedByID).Scan(&userTestID)
+        `, userID, testID, assignedByID, deadline, window.AvailableFrom, window.AvailableUntil).Scan(&userTestID)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to assign test to user: %w", err)
@@ -73,14 +133,13 @@ func (r *TestRepository) AssignTestToUser(ctx context.Context, userID int, testI
 	return userTestID, nil
 }
 
-// AssignPendingTest создает отложенное назначение теста
-func (r *TestRepository) AssignPendingTest(ctx context.Context, telegramUsername string, testID int, assignedByID int) (int, error) {
+func assignPendingTest(ctx context.Context, q querier, telegramUsername string, testID int, assignedByID int, deadline *time.Time, window model.AvailabilityWindow) (int, error) {
 	var userTestID int
-	err := r.db.QueryRow(ctx, `
-                INSERT INTO user_tests (pending_username, test_id, assigned_by, status, created_at, updated_at) 
-                VALUES ($1, $2, $3, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
+	err := q.QueryRow(ctx, `
+                INSERT INTO user_tests (pending_username, test_id, assigned_by, status, deadline, available_from, available_until, created_at, updated_at) 
+                VALUES ($1, $2, $3, 'pending', $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) 
                 RETURNING id
-        `, telegramUsername, testID, assignedByID).Scan(&userTestID)
+        `, telegramUsername, testID, assignedByID, deadline, window.AvailableFrom, window.AvailableUntil).Scan(&userTestID)
 
 	if err != nil {
 		return 0, fmt.Errorf("failed to assign pending test: %w", err)
@@ -88,6 +147,68 @@ func (r *TestRepository) AssignPendingTest(ctx context.Context, telegramUsername
 	return userTestID, nil
 }
 
+// BulkAssignTest назначает тест списку пользователей в одной транзакции: зарегистрированным - сразу,
+// остальным - отложенно. Пользователи, у которых тест уже назначен или проходится, получают статус duplicate.
+// При любой ошибке назначения не сохраняется ни одно.
+func (r *TestRepository) BulkAssignTest(ctx context.Context, testID int, assignedByID int, usernames []string, deadline *time.Time, window model.AvailabilityWindow) ([]model.BulkAssignmentResult, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Сериализуем назначения одного теста, чтобы параллельные загрузки списков не создали дубли
+	_, err = tx.Exec(ctx, "SELECT id FROM tests WHERE id = $1 FOR UPDATE", testID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock test %d: %w", testID, err)
+	}
+
+	results := make([]model.BulkAssignmentResult, 0, len(usernames))
+	for _, username := range usernames {
+		result := model.BulkAssignmentResult{Username: username}
+
+		var userID int
+		err := tx.QueryRow(ctx, "SELECT id FROM users WHERE telegram_username = $1", username).Scan(&userID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+		}
+
+		var assigned bool
+		err = tx.QueryRow(ctx, `
+            SELECT EXISTS (
+                SELECT 1
+                FROM user_tests
+                WHERE test_id = $1
+                  AND status IN ('pending', 'assigned', 'in_progress')
+                  AND (user_id = $2 OR pending_username = $3)
+            )
+        `, testID, userID, username).Scan(&assigned)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check assignment for %s: %w", username, err)
+		}
+
+		switch {
+		case assigned:
+			result.Status = model.BulkAssignDuplicate
+		case userID != 0:
+			result.UserTestID, err = assignTestToUser(ctx, tx, userID, testID, assignedByID, deadline, window)
+			result.Status = model.BulkAssignAssigned
+		default:
+			result.UserTestID, err = assignPendingTest(ctx, tx, username, testID, assignedByID, deadline, window)
+			result.Status = model.BulkAssignPending
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return results, nil
+}
+
 // GetLastTestForUserWithFinishStatus получает последний завершённый тест для пользователя со статусом "finished"
 func (r *TestRepository) GetLastTestForUserWithFinishStatus(ctx context.Context, userID int) (*model.Test, error) {
 	query := `
@@ -117,126 +238,450 @@ func (r *TestRepository) GetLastTestForUserWithFinishStatus(ctx context.Context,
 	return &test, nil
 }
 
-// GetAvailableTestsForUser получает список доступных тестов для пользователя
-func (r *TestRepository) GetAvailableTestsForUser(ctx context.Context, userID int) ([]model.Test, error) {
+// GetAvailableAssignmentsForUser получает назначения, которые пользователь может начать, в порядке назначения:
+// не начатые до срока и завершенные, для которых доступна повторная попытка. Назначения, окно доступности
+// которых еще не открылось, тоже возвращаются, чтобы кандидат видел, когда сможет начать тест
+func (r *TestRepository) GetAvailableAssignmentsForUser(ctx context.Context, userID int) ([]model.Assignment, error) {
 	query := `
-                SELECT t.id, t.test_name, t.test_type, t.duration, t.question_count
-                FROM tests t
-                JOIN user_tests ut ON t.id = ut.test_id
-                WHERE ut.user_id = $1 AND ut.status = 'assigned'
-        `
-
+        SELECT ` + assignmentColumns + assignmentTables + `
+        LEFT JOIN LATERAL (
+            SELECT COUNT(*) AS attempts,
+                   BOOL_OR(a.status = 'in_progress') AS in_progress,
+                   MAX(a.end_time) AS last_end_time
+            FROM user_tests a
+            WHERE a.id = ut.id OR a.attempt_of = ut.id
+        ) attempts ON TRUE
+        WHERE ut.user_id = $1 AND ut.attempt_of IS NULL
+          AND (ut.available_until IS NULL OR ut.available_until > CURRENT_TIMESTAMP)
+          AND (
+              (ut.status = 'assigned' AND (ut.deadline IS NULL OR ut.deadline > CURRENT_TIMESTAMP))
+              OR (ut.status IN ('pending_review', 'finished')
+                  AND attempts.attempts < t.max_attempts
+                  AND NOT attempts.in_progress
+                  AND (attempts.last_end_time IS NULL OR attempts.last_end_time + t.retake_cooldown <= CURRENT_TIMESTAMP))
+          )
+        ORDER BY ut.created_at, ut.id
+    `
 	rows, err := r.db.Query(ctx, query, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query available tests: %w", err)
+		return nil, fmt.Errorf("failed to query available assignments: %w", err)
 	}
 	defer rows.Close()
 
-	var tests []model.Test
+	var assignments []model.Assignment
 	for rows.Next() {
-		var test model.Test
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		assignments = append(assignments, *assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error in rows: %w", err)
+	}
+	return assignments, nil
+}
+
+// ExpireOverdueAssignments переводит назначенные и отложенные назначения с истекшим сроком в статус expired
+// и возвращает их для уведомлений. Обновление атомарно, поэтому каждое назначение возвращается один раз.
+func (r *TestRepository) ExpireOverdueAssignments(ctx context.Context) ([]model.ExpiredAssignment, error) {
+	query := `
+        WITH expired AS (
+            UPDATE user_tests
+            SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+            WHERE status IN ('pending', 'assigned')
+              AND deadline IS NOT NULL
+              AND deadline <= CURRENT_TIMESTAMP
+            RETURNING id, user_id, test_id, assigned_by, pending_username, deadline
+        )
+        SELECT e.id, t.test_name, COALESCE(u.telegram_username, e.pending_username, ''),
+               u.telegram_id, hr.telegram_id, e.deadline
+        FROM expired e
+        JOIN tests t ON t.id = e.test_id
+        LEFT JOIN users u ON u.id = e.user_id
+        LEFT JOIN users hr ON hr.id = e.assigned_by
+        ORDER BY e.id
+    `
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []model.ExpiredAssignment
+	for rows.Next() {
+		var assignment model.ExpiredAssignment
 		err := rows.Scan(
-			&test.ID,
-			&test.TestName,
-			&test.TestType,
-			&test.Duration,
-			&test.QuestionCount,
+			&assignment.UserTestID,
+			&assignment.TestName,
+			&assignment.CandidateUsername,
+			&assignment.CandidateTelegramID,
+			&assignment.HRTelegramID,
+			&assignment.Deadline,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan test: %w", err)
+			return nil, fmt.Errorf("failed to scan expired assignment: %w", err)
 		}
-		tests = append(tests, test)
+		expired = append(expired, assignment)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error in rows: %w", err)
+	}
+	return expired, nil
+}
 
-	if err = rows.Err(); err != nil {
+// ClaimDeadlineReminders отмечает напоминание reminderKey отправленным для назначений, до срока которых осталось
+// не больше before, но больше notAfter (окно следующего, более позднего напоминания), и возвращает их для отправки.
+// Назначения, созданные уже внутри окна, пропускаются.
+func (r *TestRepository) ClaimDeadlineReminders(ctx context.Context, reminderKey string, before, notAfter time.Duration) ([]model.Reminder, error) {
+	return r.claimReminders(ctx, reminderKey, `
+                ut.deadline IS NOT NULL
+                AND ut.deadline <= CURRENT_TIMESTAMP + make_interval(secs => $2)
+                AND ut.deadline > CURRENT_TIMESTAMP + make_interval(secs => $3)
+                AND ut.created_at <= ut.deadline - make_interval(secs => $2)
+        `, before.Seconds(), notAfter.Seconds())
+}
+
+// ClaimAssignmentReminders отмечает напоминание reminderKey отправленным для назначений,
+// созданных не менее after назад, и возвращает их для отправки
+func (r *TestRepository) ClaimAssignmentReminders(ctx context.Context, reminderKey string, after time.Duration) ([]model.Reminder, error) {
+	return r.claimReminders(ctx, reminderKey, `
+                ut.created_at <= CURRENT_TIMESTAMP - make_interval(secs => $2)
+                AND (ut.deadline IS NULL OR ut.deadline > CURRENT_TIMESTAMP)
+        `, after.Seconds())
+}
+
+// claimReminders выбирает назначенные, но не начатые тесты по условию condition, для которых напоминание
+// reminderKey еще не отправлялось, и записывает его в sent_reminders одним запросом.
+// Параметр $1 - ключ напоминания, args - параметры условия начиная с $2.
+func (r *TestRepository) claimReminders(ctx context.Context, reminderKey string, condition string, args ...any) ([]model.Reminder, error) {
+	query := `
+        WITH due AS (
+            SELECT ut.id
+            FROM user_tests ut
+            WHERE ut.status = 'assigned'
+              AND ut.user_id IS NOT NULL
+              AND ` + condition + `
+              AND NOT EXISTS (
+                SELECT 1 FROM sent_reminders sr
+                WHERE sr.user_test_id = ut.id AND sr.reminder_key = $1
+              )
+        ), claimed AS (
+            INSERT INTO sent_reminders (user_test_id, reminder_key)
+            SELECT id, $1 FROM due
+            ON CONFLICT (user_test_id, reminder_key) DO NOTHING
+            RETURNING user_test_id
+        )
+        SELECT ut.id, u.telegram_id, t.test_name, ut.deadline
+        FROM claimed c
+        JOIN user_tests ut ON ut.id = c.user_test_id
+        JOIN users u ON u.id = ut.user_id
+        JOIN tests t ON t.id = ut.test_id
+        WHERE u.telegram_id IS NOT NULL
+        ORDER BY ut.id
+    `
+	rows, err := r.db.Query(ctx, query, append([]any{reminderKey}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders %s: %w", reminderKey, err)
+	}
+	defer rows.Close()
+
+	var reminders []model.Reminder
+	for rows.Next() {
+		var reminder model.Reminder
+		if err := rows.Scan(&reminder.UserTestID, &reminder.TelegramID, &reminder.TestName, &reminder.Deadline); err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error in rows: %w", err)
 	}
+	return reminders, nil
+}
 
-	return tests, nil
+// assignmentColumns колонки назначения вместе с тестом и кандидатом
+const assignmentColumns = `
+        ut.id, ut.test_id, t.test_name, t.duration, t.question_count, ut.assigned_by,
+        COALESCE(u.telegram_username, ut.pending_username, ''), u.telegram_id,
+        ut.status, ut.deadline, ut.created_at, ut.available_from, ut.available_until
+`
+
+// assignmentTables таблицы для выборки колонок assignmentColumns
+const assignmentTables = `
+        FROM user_tests ut
+        JOIN tests t ON t.id = ut.test_id
+        LEFT JOIN users u ON u.id = ut.user_id
+`
+
+// scanAssignment читает назначение, выбранное с колонками assignmentColumns
+func scanAssignment(row pgx.Row) (*model.Assignment, error) {
+	var assignment model.Assignment
+	err := row.Scan(
+		&assignment.UserTestID,
+		&assignment.TestID,
+		&assignment.TestName,
+		&assignment.Duration,
+		&assignment.QuestionCount,
+		&assignment.AssignedBy,
+		&assignment.CandidateUsername,
+		&assignment.CandidateTelegramID,
+		&assignment.Status,
+		&assignment.Deadline,
+		&assignment.CreatedAt,
+		&assignment.AvailableFrom,
+		&assignment.AvailableUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &assignment, nil
 }
 
-// CheckTestAssignment проверяет, назначен ли тест пользователю
-func (r *TestRepository) CheckTestAssignment(ctx context.Context, userID, testID int) (bool, error) {
+// GetAssignmentsByHR получает назначения HR, кроме отмененных, новые первыми
+func (r *TestRepository) GetAssignmentsByHR(ctx context.Context, hrID int, limit int, offset int) ([]model.Assignment, error) {
 	query := `
-                SELECT EXISTS (
-                        SELECT 1 
-                        FROM user_tests 
-                        WHERE user_id = $1 
-                        AND test_id = $2 
-                        AND status = 'assigned'
-                )
-        `
+        SELECT ` + assignmentColumns + assignmentTables + `
+        WHERE ut.assigned_by = $1 AND ut.status <> 'cancelled' AND ut.attempt_of IS NULL
+        ORDER BY ut.created_at DESC, ut.id DESC
+        LIMIT $2 OFFSET $3
+    `
+	rows, err := r.db.Query(ctx, query, hrID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query assignments: %w", err)
+	}
+	defer rows.Close()
+
+	var assignments []model.Assignment
+	for rows.Next() {
+		assignment, err := scanAssignment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		assignments = append(assignments, *assignment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error in rows: %w", err)
+	}
+	return assignments, nil
+}
 
-	var exists bool
-	err := r.db.QueryRow(ctx, query, userID, testID).Scan(&exists)
+// CountAssignmentsByHR возвращает количество назначений HR, кроме отмененных
+func (r *TestRepository) CountAssignmentsByHR(ctx context.Context, hrID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM user_tests WHERE assigned_by = $1 AND status <> 'cancelled' AND attempt_of IS NULL", hrID).Scan(&count)
 	if err != nil {
-		return false, fmt.Errorf("failed to check test assignment: %w", err)
+		return 0, fmt.Errorf("failed to count assignments: %w", err)
 	}
+	return count, nil
+}
 
-	return exists, nil
+// GetAssignment получает назначение по ID, возвращает nil, если назначение не найдено
+func (r *TestRepository) GetAssignment(ctx context.Context, userTestID int) (*model.Assignment, error) {
+	query := `SELECT ` + assignmentColumns + assignmentTables + ` WHERE ut.id = $1`
+	assignment, err := scanAssignment(r.db.QueryRow(ctx, query, userTestID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+	return assignment, nil
 }
 
-// StartTest начинает тест для пользователя и возвращает ID назначения теста (user_test_id)
-func (r *TestRepository) StartTest(ctx context.Context, userID, testID int) (int, error) {
-	// Проверяем, существует ли назначение теста
-	var existingUserTestID int
-	checkQuery := `
-        SELECT id FROM user_tests 
-        WHERE user_id = $1 AND test_id = $2 AND status = 'assigned'
-    `
-	err := r.db.QueryRow(ctx, checkQuery, userID, testID).Scan(&existingUserTestID)
-	if err != nil && err != pgx.ErrNoRows {
-		return 0, fmt.Errorf("failed to check existing test assignment: %w", err)
+// CancelAssignment отменяет еще не начатое назначение HR. Возвращает false, если такого назначения нет
+func (r *TestRepository) CancelAssignment(ctx context.Context, userTestID int, hrID int) (bool, error) {
+	commandTag, err := r.db.Exec(ctx, `
+        UPDATE user_tests
+        SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND assigned_by = $2 AND status IN ('pending', 'assigned')
+    `, userTestID, hrID)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel assignment: %w", err)
 	}
+	return commandTag.RowsAffected() > 0, nil
+}
 
-	if err == pgx.ErrNoRows {
-		// Если записи нет, создаем новую
-		log.Printf("No existing test assignment found, creating new for user %d and test %d", userID, testID)
-		query := `
+// ReassignTest заменяет тест в еще не начатом назначении HR. Возвращает false, если такого назначения нет
+func (r *TestRepository) ReassignTest(ctx context.Context, userTestID int, hrID int, testID int) (bool, error) {
+	commandTag, err := r.db.Exec(ctx, `
+        UPDATE user_tests
+        SET test_id = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND assigned_by = $2 AND status IN ('pending', 'assigned')
+    `, userTestID, hrID, testID)
+	if err != nil {
+		return false, fmt.Errorf("failed to reassign test: %w", err)
+	}
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// TransferAssignment передает назначение другому HR вместе со всеми его попытками: уведомления о начатых
+// и завершенных попытках и очередь проверки идут новому HR. Возвращает false, если назначения HR нет или оно отменено
+func (r *TestRepository) TransferAssignment(ctx context.Context, userTestID int, hrID int, newHRID int) (bool, error) {
+	commandTag, err := r.db.Exec(ctx, `
+        UPDATE user_tests
+        SET assigned_by = $3, updated_at = CURRENT_TIMESTAMP
+        WHERE (id = $1 OR attempt_of = $1)
+          AND EXISTS (
+              SELECT 1 FROM user_tests assignment
+              WHERE assignment.id = $1 AND assignment.assigned_by = $2 AND assignment.status <> 'cancelled'
+          )
+    `, userTestID, hrID, newHRID)
+	if err != nil {
+		return false, fmt.Errorf("failed to transfer assignment: %w", err)
+	}
+	return commandTag.RowsAffected() > 0, nil
+}
+
+// StartAttempt начинает попытку по назначению пользователя и возвращает ID попытки (user_test_id).
+// Не начатое назначение становится первой попыткой. Для завершенного назначения создается новая строка,
+// если не исчерпан лимит попыток теста (max_attempts) и прошла пауза после предыдущей (retake_cooldown)
+func (r *TestRepository) StartAttempt(ctx context.Context, userID int, assignmentID int) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем назначение, чтобы одновременные нажатия не начали две попытки
+	var status string
+	var testID, assignedBy, maxAttempts int
+	var deadlinePassed, outsideWindow bool
+	var window model.AvailabilityWindow
+	err = tx.QueryRow(ctx, `
+        SELECT ut.status, ut.test_id, ut.assigned_by, t.max_attempts,
+               COALESCE(ut.deadline <= CURRENT_TIMESTAMP, FALSE),
+               COALESCE(ut.available_from > CURRENT_TIMESTAMP, FALSE) OR COALESCE(ut.available_until <= CURRENT_TIMESTAMP, FALSE),
+               ut.available_from, ut.available_until
+        FROM user_tests ut
+        JOIN tests t ON t.id = ut.test_id
+        WHERE ut.id = $1 AND ut.user_id = $2 AND ut.attempt_of IS NULL
+        FOR UPDATE OF ut
+    `, assignmentID, userID).Scan(&status, &testID, &assignedBy, &maxAttempts, &deadlinePassed, &outsideWindow,
+		&window.AvailableFrom, &window.AvailableUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrAssignmentNotAvailable
+		}
+		return 0, fmt.Errorf("failed to get assignment: %w", err)
+	}
+	// Окно доступности ограничивает и первую, и повторные попытки
+	if outsideWindow {
+		return 0, &AvailabilityWindowError{Window: window}
+	}
+
+	var userTestID int
+	switch status {
+	case model.UserTestStatusAssigned:
+		if deadlinePassed {
+			return 0, ErrAssignmentNotAvailable
+		}
+		err = tx.QueryRow(ctx, `
+            UPDATE user_tests
+            SET status = 'in_progress',
+                start_time = CURRENT_TIMESTAMP,
+                current_question_index = 0,
+                correct_answers_count = 0,
+                timer_deadline = CURRENT_TIMESTAMP + (SELECT duration * INTERVAL '1 minute' FROM tests WHERE id = $2),
+                end_time = NULL,
+                attempt_number = 1
+            WHERE id = $1
+            RETURNING id
+        `, assignmentID, testID).Scan(&userTestID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to start test: %w", err)
+		}
+	case model.UserTestStatusPendingReview, model.UserTestStatusFinished:
+		var attempts int
+		var inProgress bool
+		var cooldownLeft int64
+		err = tx.QueryRow(ctx, `
+            SELECT COUNT(*),
+                   COALESCE(BOOL_OR(a.status = 'in_progress'), FALSE),
+                   COALESCE(CEIL(EXTRACT(EPOCH FROM MAX(a.end_time) + t.retake_cooldown - CURRENT_TIMESTAMP))::BIGINT, 0)
+            FROM user_tests a
+            JOIN tests t ON t.id = a.test_id
+            WHERE a.id = $1 OR a.attempt_of = $1
+            GROUP BY t.retake_cooldown
+        `, assignmentID).Scan(&attempts, &inProgress, &cooldownLeft)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count attempts: %w", err)
+		}
+		if inProgress {
+			return 0, ErrAssignmentNotAvailable
+		}
+		if attempts >= maxAttempts {
+			return 0, ErrAttemptsExhausted
+		}
+		if cooldownLeft > 0 {
+			return 0, &RetakeCooldownError{AvailableAt: time.Now().Add(time.Duration(cooldownLeft) * time.Second)}
+		}
+
+		err = tx.QueryRow(ctx, `
             INSERT INTO user_tests (
-                user_id, test_id, status, start_time, 
-                current_question_index, correct_answers_count, 
-                timer_deadline
+                user_id, test_id, assigned_by, status, start_time,
+                current_question_index, correct_answers_count,
+                timer_deadline, attempt_of, attempt_number
             )
             VALUES (
-                $1, $2, 'in_progress', CURRENT_TIMESTAMP, 
-                0, 0, 
-                CURRENT_TIMESTAMP + (SELECT duration * INTERVAL '1 minute' FROM tests WHERE id = $2)
+                $1, $2, $3, 'in_progress', CURRENT_TIMESTAMP,
+                0, 0,
+                CURRENT_TIMESTAMP + (SELECT duration * INTERVAL '1 minute' FROM tests WHERE id = $2),
+                $4, $5
             )
             RETURNING id
-        `
-		var userTestID int
-		err = r.db.QueryRow(ctx, query, userID, testID).Scan(&userTestID)
+        `, userID, testID, assignedBy, assignmentID, attempts+1).Scan(&userTestID)
 		if err != nil {
-			return 0, fmt.Errorf("failed to create new test assignment: %w", err)
+			return 0, fmt.Errorf("failed to create test attempt: %w", err)
 		}
-		log.Printf("Created new test assignment with ID %d", userTestID)
-		return userTestID, nil
+	default:
+		return 0, ErrAssignmentNotAvailable
 	}
 
-	// Если запись существует, обновляем ее
-	log.Printf("Found existing test assignment with ID %d, updating", existingUserTestID)
-	query := `
-        UPDATE user_tests 
-        SET status = 'in_progress',
-            start_time = CURRENT_TIMESTAMP,
-            current_question_index = 0,
-            correct_answers_count = 0,
-            timer_deadline = CURRENT_TIMESTAMP + (SELECT duration * INTERVAL '1 minute' FROM tests WHERE id = $2),
-            end_time = NULL
-        WHERE id = $1
-        RETURNING id
-    `
-	var userTestID int
-	err = r.db.QueryRow(ctx, query, existingUserTestID, testID).Scan(&userTestID)
-	if err != nil {
-		return 0, fmt.Errorf("failed to start test: %w", err)
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("Started test with ID %d for user %d", userTestID, userID)
+	log.Printf("Started test attempt %d of assignment %d for user %d", userTestID, assignmentID, userID)
 	return userTestID, nil
 }
 
+// AbortAttempt отменяет только что начатую попытку, подготовить которую не удалось: первая попытка
+// возвращается в статус assigned, повторная удаляется. Так попытка не расходует лимит max_attempts.
+// Попытки, по которым уже есть ответы, не отменяются
+func (r *TestRepository) AbortAttempt(ctx context.Context, userTestID int) error {
+	_, err := r.db.Exec(ctx, `
+        DELETE FROM user_tests
+        WHERE id = $1 AND attempt_of IS NOT NULL AND status = 'in_progress'
+          AND NOT EXISTS (SELECT 1 FROM answers WHERE user_test_id = $1)
+    `, userTestID)
+	if err != nil {
+		return fmt.Errorf("failed to delete test attempt: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, `
+        UPDATE user_tests
+        SET status = 'assigned',
+            start_time = NULL,
+            timer_deadline = NULL,
+            message_id = NULL,
+            selected_question_ids = '{}',
+            option_orders = NULL,
+            current_question_index = NULL,
+            correct_answers_count = NULL,
+            question_started_at = NULL,
+            question_message_id = NULL,
+            question_selection = 0,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND attempt_of IS NULL AND status = 'in_progress'
+          AND NOT EXISTS (SELECT 1 FROM answers WHERE user_test_id = $1)
+    `, userTestID)
+	if err != nil {
+		return fmt.Errorf("failed to reset test attempt: %w", err)
+	}
+	return nil
+}
+
 // GetPendingTests получает отложенные тесты для пользователя
 func (r *TestRepository) GetPendingTests(ctx context.Context, telegramUsername string) ([]struct {
 	TestID       int
@@ -307,7 +752,8 @@ func (r *TestRepository) UpdateTimerMessageID(ctx context.Context, userTestID in
 // GetQuestionsByTestID получает все вопросы для конкретного теста
 func (r *TestRepository) GetQuestionsByTestID(ctx context.Context, testID int) ([]model.Question, error) {
 	query := `
-        SELECT id, test_id, question_text, answer_type, correct_answer, test_options
+        SELECT id, test_id, question_text, answer_type, correct_answer, test_options,
+               answer_matcher, accepted_answers, numeric_tolerance, time_limit_seconds
         FROM questions
         WHERE test_id = $1
         ORDER BY id
@@ -321,7 +767,7 @@ func (r *TestRepository) GetQuestionsByTestID(ctx context.Context, testID int) (
 	var questions []model.Question
 	for rows.Next() {
 		var q model.Question
-		var testOptions []byte
+		var testOptions, acceptedAnswers []byte
 		err := rows.Scan(
 			&q.ID,
 			&q.TestID,
@@ -329,6 +775,10 @@ func (r *TestRepository) GetQuestionsByTestID(ctx context.Context, testID int) (
 			&q.AnswerType,
 			&q.CorrectAnswer,
 			&testOptions,
+			&q.AnswerMatcher,
+			&acceptedAnswers,
+			&q.NumericTolerance,
+			&q.TimeLimitSeconds,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan question: %w", err)
@@ -340,6 +790,11 @@ func (r *TestRepository) GetQuestionsByTestID(ctx context.Context, testID int) (
 				return nil, fmt.Errorf("failed to unmarshal test options: %w", err)
 			}
 		}
+		if len(acceptedAnswers) > 0 {
+			if err := json.Unmarshal(acceptedAnswers, &q.AcceptedAnswers); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal accepted answers: %w", err)
+			}
+		}
 		questions = append(questions, q)
 	}
 
@@ -372,22 +827,373 @@ func (r *TestRepository) UpdateCurrentQuestionIndex(ctx context.Context, userID
 
 // UpdateUserTestState обновляет состояние теста в таблице user_tests
 func (r *TestRepository) UpdateUserTestState(ctx context.Context, userTestID int, currentQuestionIndex int, correctAnswersCount int) error {
-	_, err := r.db.Exec(ctx,
-		"UPDATE user_tests SET current_question_index = $1, correct_answers_count = $2 WHERE id = $3",
-		currentQuestionIndex, correctAnswersCount, userTestID)
+	// Переход к вопросу запускает отсчет времени на ответ, сообщение с новым вопросом сохраняется после отправки
+	query := `
+        UPDATE user_tests
+        SET current_question_index = $1,
+            correct_answers_count = $2,
+            question_started_at = CURRENT_TIMESTAMP,
+            question_message_id = NULL,
+            question_selection = 0
+        WHERE id = $3
+    `
+	_, err := r.db.Exec(ctx, query, currentQuestionIndex, correctAnswersCount, userTestID)
 	if err != nil {
 		return fmt.Errorf("failed to update user test state: %w", err)
 	}
 	return nil
 }
 
-// SaveAnswer сохраняет ответ пользователя в таблицу answers
-func (r *TestRepository) SaveAnswer(ctx context.Context, userTestID int, questionID int, userAnswer string, isCorrect bool) error {
+// elapsedSeconds выражение для времени ответа на текущий вопрос назначения $1, отсчитываемого от его показа
+const elapsedSeconds = "(SELECT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - question_started_at)::INT FROM user_tests WHERE id = $1)"
+
+// SaveAnswerAndAdvance сохраняет ответ на вопрос questionIndex и переводит кандидата к следующему вопросу в одной транзакции.
+// Назначение блокируется так же, как в ExpireQuestion, поэтому ответ и истечение времени не засчитываются оба.
+// Возвращает false без сохранения ответа, если кандидат уже перешел к другому вопросу или тест завершен.
+func (r *TestRepository) SaveAnswerAndAdvance(ctx context.Context, userTestID int, questionIndex int, questionID int, userAnswer string, isCorrect bool, reviewStatus string) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var elapsed *int
+	err = tx.QueryRow(ctx, `
+        SELECT EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - question_started_at)::INT
+        FROM user_tests
+        WHERE id = $1 AND status = $2 AND current_question_index = $3
+        FOR UPDATE
+    `, userTestID, model.UserTestStatusInProgress, questionIndex).Scan(&elapsed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock user test: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO answers (user_test_id, question_id, user_answer, is_correct, review_status, elapsed_seconds)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, userTestID, questionID, userAnswer, isCorrect, reviewStatus, elapsed)
+	if err != nil {
+		return false, fmt.Errorf("failed to save answer: %w", err)
+	}
+
+	// Переход к вопросу запускает отсчет времени на ответ, сообщение с новым вопросом сохраняется после отправки
+	commandTag, err := tx.Exec(ctx, `
+        UPDATE user_tests
+        SET current_question_index = $2 + 1,
+            correct_answers_count = COALESCE(correct_answers_count, 0) + CASE WHEN $3 THEN 1 ELSE 0 END,
+            question_started_at = CURRENT_TIMESTAMP,
+            question_message_id = NULL,
+            question_selection = 0
+        WHERE id = $1 AND current_question_index = $2
+    `, userTestID, questionIndex, isCorrect)
+	if err != nil {
+		return false, fmt.Errorf("failed to move to next question: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ReplaceAnswer сохраняет ответ на вопрос, заменяя предыдущий ответ на тот же вопрос.
+// Используется в режиме свободной навигации, где кандидат может менять ответы до отправки теста.
+// Уникальный индекс (user_test_id, question_id) не дает быстрым повторным нажатиям оставить два ответа.
+func (r *TestRepository) ReplaceAnswer(ctx context.Context, userTestID int, questionID int, userAnswer string, isCorrect bool, reviewStatus string) error {
+	query := `
+        INSERT INTO answers (user_test_id, question_id, user_answer, is_correct, review_status, elapsed_seconds)
+        VALUES ($1, $2, $3, $4, $5, ` + elapsedSeconds + `)
+        ON CONFLICT (user_test_id, question_id) DO UPDATE
+        SET user_answer = EXCLUDED.user_answer,
+            is_correct = EXCLUDED.is_correct,
+            review_status = EXCLUDED.review_status,
+            elapsed_seconds = EXCLUDED.elapsed_seconds,
+            updated_at = CURRENT_TIMESTAMP
+    `
+	_, err := r.db.Exec(ctx, query, userTestID, questionID, userAnswer, isCorrect, reviewStatus)
+	if err != nil {
+		return fmt.Errorf("failed to replace answer: %w", err)
+	}
+	return nil
+}
+
+// UpdateUserTestQuestionIndex обновляет только текущий вопрос, не меняя correct_answers_count
+func (r *TestRepository) UpdateUserTestQuestionIndex(ctx context.Context, userTestID int, currentQuestionIndex int) error {
 	_, err := r.db.Exec(ctx,
-		"INSERT INTO answers (user_test_id, question_id, user_answer, is_correct) VALUES ($1, $2, $3, $4)",
-		userTestID, questionID, userAnswer, isCorrect)
+		"UPDATE user_tests SET current_question_index = $1, question_started_at = CURRENT_TIMESTAMP WHERE id = $2",
+		currentQuestionIndex, userTestID)
+	if err != nil {
+		return fmt.Errorf("failed to update current question index: %w", err)
+	}
+	return nil
+}
+
+// SaveQuestionMessageID сохраняет ID сообщения с текущим вопросом, в котором обновляется обратный отсчет
+func (r *TestRepository) SaveQuestionMessageID(ctx context.Context, userTestID int, messageID int) error {
+	_, err := r.db.Exec(ctx, "UPDATE user_tests SET question_message_id = $1 WHERE id = $2", messageID, userTestID)
+	if err != nil {
+		return fmt.Errorf("failed to save question message ID: %w", err)
+	}
+	return nil
+}
+
+// SaveQuestionSelection сохраняет отмеченные варианты текущего вопроса с несколькими ответами
+func (r *TestRepository) SaveQuestionSelection(ctx context.Context, userTestID int, selection uint64) error {
+	_, err := r.db.Exec(ctx, "UPDATE user_tests SET question_selection = $1 WHERE id = $2", int64(selection), userTestID)
 	if err != nil {
-		return fmt.Errorf("failed to save answer: %w", err)
+		return fmt.Errorf("failed to save question selection: %w", err)
+	}
+	return nil
+}
+
+// ExpireQuestion записывает отсутствие ответа на вопрос questionIndex, время на который истекло, и переходит к следующему вопросу.
+// Возвращает false, если кандидат уже ответил на вопрос или тест завершен.
+func (r *TestRepository) ExpireQuestion(ctx context.Context, userTestID int, questionIndex int) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем назначение так же, как SaveAnswerAndAdvance, чтобы ответ кандидата и истечение времени не засчитались оба
+	var questionID *int
+	err = tx.QueryRow(ctx, `
+        SELECT selected_question_ids[current_question_index + 1]
+        FROM user_tests
+        WHERE id = $1 AND status = $2 AND current_question_index = $3
+        FOR UPDATE
+    `, userTestID, model.UserTestStatusInProgress, questionIndex).Scan(&questionID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock user test: %w", err)
+	}
+	if questionID == nil {
+		return false, fmt.Errorf("question %d not found in user test %d", questionIndex, userTestID)
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO answers (user_test_id, question_id, user_answer, is_correct, elapsed_seconds, timed_out)
+        VALUES ($1, $2, '', FALSE, `+elapsedSeconds+`, TRUE)
+    `, userTestID, *questionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to save timed out answer: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE user_tests
+        SET current_question_index = current_question_index + 1,
+            question_started_at = CURRENT_TIMESTAMP,
+            question_message_id = NULL,
+            question_selection = 0
+        WHERE id = $1
+    `, userTestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move to next question: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// IsFreeNavigation сообщает, включена ли свободная навигация для теста назначения
+func (r *TestRepository) IsFreeNavigation(ctx context.Context, userTestID int) (bool, error) {
+	var freeNavigation bool
+	err := r.db.QueryRow(ctx, `
+        SELECT t.free_navigation
+        FROM user_tests ut
+        JOIN tests t ON ut.test_id = t.id
+        WHERE ut.id = $1
+    `, userTestID).Scan(&freeNavigation)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, fmt.Errorf("user test %d not found", userTestID)
+		}
+		return false, fmt.Errorf("failed to get navigation mode: %w", err)
+	}
+	return freeNavigation, nil
+}
+
+// FinishUserTest завершает тест в процессе прохождения и пересчитывает correct_answers_count по таблице answers.
+// Если есть ответы, ожидающие проверки, тест получает статус pending_review.
+// Возвращает новый статус или пустую строку, если тест уже был завершен ранее.
+func (r *TestRepository) FinishUserTest(ctx context.Context, userTestID int, endTime time.Time) (string, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Блокируем назначение, чтобы параллельная проверка ответа не пропустила завершение теста
+	var status string
+	err = tx.QueryRow(ctx, "SELECT status FROM user_tests WHERE id = $1 FOR UPDATE", userTestID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("user test %d not found", userTestID)
+		}
+		return "", fmt.Errorf("failed to lock user test: %w", err)
+	}
+	if status != model.UserTestStatusInProgress {
+		return "", nil
+	}
+
+	query := `
+        UPDATE user_tests
+        SET status = CASE
+                WHEN EXISTS (SELECT 1 FROM answers WHERE user_test_id = $1 AND review_status = $3)
+                THEN $4 ELSE $5
+            END,
+            correct_answers_count = (SELECT COUNT(DISTINCT question_id) FROM answers WHERE user_test_id = $1 AND is_correct),
+            end_time = $2,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+        RETURNING status
+    `
+	err = tx.QueryRow(ctx, query, userTestID, endTime, model.ReviewStatusPending,
+		model.UserTestStatusPendingReview, model.UserTestStatusFinished).Scan(&status)
+	if err != nil {
+		return "", fmt.Errorf("failed to finish user test: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return status, nil
+}
+
+// GetNextPendingReview получает самый старый ответ, ожидающий ручной проверки
+func (r *TestRepository) GetNextPendingReview(ctx context.Context) (*model.PendingReview, error) {
+	query := `
+        SELECT a.id, a.user_test_id, a.question_id, a.user_answer, a.review_status, a.created_at,
+               q.question_text, q.correct_answer, u.telegram_username, t.test_name
+        FROM answers a
+        JOIN questions q ON q.id = a.question_id
+        JOIN user_tests ut ON ut.id = a.user_test_id
+        JOIN users u ON u.id = ut.user_id
+        JOIN tests t ON t.id = ut.test_id
+        WHERE a.review_status = $1
+        ORDER BY a.created_at, a.id
+        LIMIT 1
+    `
+	var review model.PendingReview
+	err := r.db.QueryRow(ctx, query, model.ReviewStatusPending).Scan(
+		&review.Answer.ID,
+		&review.Answer.UserTestID,
+		&review.Answer.QuestionID,
+		&review.Answer.UserAnswer,
+		&review.Answer.ReviewStatus,
+		&review.Answer.CreatedAt,
+		&review.QuestionText,
+		&review.CorrectAnswer,
+		&review.Username,
+		&review.TestName,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next pending review: %w", err)
+	}
+	return &review, nil
+}
+
+// CountPendingReviews возвращает количество ответов, ожидающих ручной проверки
+func (r *TestRepository) CountPendingReviews(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM answers WHERE review_status = $1", model.ReviewStatusPending).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending reviews: %w", err)
+	}
+	return count, nil
+}
+
+// ReviewAnswer сохраняет оценку менеджера. Если это был последний непроверенный ответ завершенного теста,
+// пересчитывает correct_answers_count и переводит тест в статус finished.
+// Возвращает ID назначения теста и признак того, что проверка теста завершена.
+func (r *TestRepository) ReviewAnswer(ctx context.Context, answerID int, reviewerID int, isCorrect bool) (int, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Сначала блокируем назначение теста, в том же порядке, что и FinishUserTest
+	var userTestID int
+	var status string
+	err = tx.QueryRow(ctx, `
+        SELECT ut.id, ut.status
+        FROM user_tests ut
+        JOIN answers a ON a.user_test_id = ut.id
+        WHERE a.id = $1
+        FOR UPDATE OF ut
+    `, answerID).Scan(&userTestID, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, fmt.Errorf("answer %d not found", answerID)
+		}
+		return 0, false, fmt.Errorf("failed to lock user test: %w", err)
+	}
+
+	commandTag, err := tx.Exec(ctx, `
+        UPDATE answers
+        SET is_correct = $2,
+            review_status = $3,
+            reviewed_by = $4,
+            reviewed_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND review_status = $5
+    `, answerID, isCorrect, model.ReviewStatusReviewed, reviewerID, model.ReviewStatusPending)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to review answer: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return 0, false, ErrAnswerAlreadyReviewed
+	}
+
+	completed := false
+	if status == model.UserTestStatusPendingReview {
+		commandTag, err = tx.Exec(ctx, `
+            UPDATE user_tests
+            SET status = $2,
+                correct_answers_count = (SELECT COUNT(DISTINCT question_id) FROM answers WHERE user_test_id = $1 AND is_correct),
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1
+            AND NOT EXISTS (SELECT 1 FROM answers WHERE user_test_id = $1 AND review_status = $3)
+        `, userTestID, model.UserTestStatusFinished, model.ReviewStatusPending)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to recalculate user test score: %w", err)
+		}
+		completed = commandTag.RowsAffected() > 0
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userTestID, completed, nil
+}
+
+// SaveReviewComment сохраняет комментарий менеджера к проверенному ответу
+func (r *TestRepository) SaveReviewComment(ctx context.Context, answerID int, comment string) error {
+	commandTag, err := r.db.Exec(ctx, `
+        UPDATE answers
+        SET review_comment = $2,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND review_status = $3
+    `, answerID, comment, model.ReviewStatusReviewed)
+	if err != nil {
+		return fmt.Errorf("failed to save review comment: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return fmt.Errorf("reviewed answer %d not found", answerID)
 	}
 	return nil
 }
@@ -430,6 +1236,80 @@ func (r *TestRepository) GetUserTestState(ctx context.Context, userTestID int) (
 	return currentQuestionIndex, correctAnswersCount, status, nil
 }
 
+// GetActiveTimers получает таймеры всех тестов в процессе прохождения
+func (r *TestRepository) GetActiveTimers(ctx context.Context) ([]model.ActiveTimer, error) {
+	query := `
+        SELECT ut.id, u.telegram_id, COALESCE(ut.message_id, 0), ut.timer_deadline, COALESCE(t.question_count, 0)
+        FROM user_tests ut
+        JOIN users u ON ut.user_id = u.id
+        JOIN tests t ON ut.test_id = t.id
+        WHERE ut.status = 'in_progress' AND ut.timer_deadline IS NOT NULL AND u.telegram_id IS NOT NULL
+        ORDER BY ut.timer_deadline
+    `
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active timers: %w", err)
+	}
+	defer rows.Close()
+
+	var timers []model.ActiveTimer
+	for rows.Next() {
+		var t model.ActiveTimer
+		if err := rows.Scan(&t.UserTestID, &t.TelegramID, &t.MessageID, &t.Deadline, &t.TotalQuestions); err != nil {
+			return nil, fmt.Errorf("failed to scan active timer: %w", err)
+		}
+		timers = append(timers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over rows: %w", err)
+	}
+
+	return timers, nil
+}
+
+// GetUserTestsProgress получает текущий вопрос, его ограничение времени и статус сразу для нескольких назначений одним запросом
+func (r *TestRepository) GetUserTestsProgress(ctx context.Context, userTestIDs []int) (map[int]model.UserTestProgress, error) {
+	// Ограничение времени на вопрос действует только в тестах без свободной навигации: в них кандидат переходит
+	// между вопросами сам, поэтому questions.time_limit_seconds не учитывается и действует только общее время теста
+	query := `
+        SELECT ut.id, COALESCE(ut.current_question_index, 0), ut.status,
+               COALESCE(ut.selected_question_ids[COALESCE(ut.current_question_index, 0) + 1], 0),
+               COALESCE(ut.question_message_id, 0), ut.question_selection,
+               ut.question_started_at + make_interval(secs => q.time_limit_seconds)
+        FROM user_tests ut
+        JOIN tests t ON ut.test_id = t.id
+        LEFT JOIN questions q ON NOT t.free_navigation
+            AND q.id = ut.selected_question_ids[COALESCE(ut.current_question_index, 0) + 1]
+        WHERE ut.id = ANY($1)
+    `
+	rows, err := r.db.Query(ctx, query, userTestIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user tests progress: %w", err)
+	}
+	defer rows.Close()
+
+	progress := make(map[int]model.UserTestProgress, len(userTestIDs))
+	for rows.Next() {
+		var id int
+		var selection int64
+		var p model.UserTestProgress
+		err := rows.Scan(&id, &p.CurrentQuestionIndex, &p.Status,
+			&p.QuestionID, &p.QuestionMessageID, &selection, &p.QuestionDeadline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user test progress: %w", err)
+		}
+		p.QuestionSelection = uint64(selection)
+		progress[id] = p
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over rows: %w", err)
+	}
+
+	return progress, nil
+}
+
 // GetUserTestIDByUserID получает ID текущего теста пользователя из таблицы user_tests
 func (r *TestRepository) GetUserTestIDByUserID(ctx context.Context, telegramID int64) (int, error) {
 	// Сначала находим user_id по telegram_id
@@ -477,7 +1357,7 @@ func (r *TestRepository) GetTestIDByUserTestID(ctx context.Context, userTestID i
 func (r *TestRepository) GetUserTestsByUserID(ctx context.Context, userID int) ([]model.UserTest, error) {
 	query := `
         SELECT id, user_id, test_id, assigned_by, status, start_time, end_time, current_question_index, 
-               correct_answers_count, timer_deadline, created_at, updated_at
+               correct_answers_count, timer_deadline, attempt_of, attempt_number, created_at, updated_at
         FROM user_tests
         WHERE user_id = $1
         ORDER BY created_at DESC
@@ -502,6 +1382,8 @@ func (r *TestRepository) GetUserTestsByUserID(ctx context.Context, userID int) (
 			&ut.CurrentQuestionIndex,
 			&ut.CorrectAnswersCount,
 			&ut.TimerDeadline,
+			&ut.AttemptOf,
+			&ut.AttemptNumber,
 			&ut.CreatedAt,
 			&ut.UpdatedAt,
 		)
@@ -521,17 +1403,24 @@ func (r *TestRepository) GetUserTestsByUserID(ctx context.Context, userID int) (
 // GetTestByID получает информацию о тесте по его ID
 func (r *TestRepository) GetTestByID(ctx context.Context, testID int) (*model.Test, error) {
 	query := `
-        SELECT id, test_name, test_type, duration, question_count, created_at, updated_at
+        SELECT id, test_name, test_type, duration, question_count,
+               max_attempts, EXTRACT(EPOCH FROM retake_cooldown)::BIGINT, free_navigation, shuffle_options,
+               created_at, updated_at
         FROM tests
         WHERE id = $1
     `
 	var test model.Test
+	var cooldownSeconds int64
 	err := r.db.QueryRow(ctx, query, testID).Scan(
 		&test.ID,
 		&test.TestName,
 		&test.TestType,
 		&test.Duration,
 		&test.QuestionCount,
+		&test.MaxAttempts,
+		&cooldownSeconds,
+		&test.FreeNavigation,
+		&test.ShuffleOptions,
 		&test.CreatedAt,
 		&test.UpdatedAt,
 	)
@@ -541,13 +1430,15 @@ func (r *TestRepository) GetTestByID(ctx context.Context, testID int) (*model.Te
 		}
 		return nil, fmt.Errorf("failed to query test: %w", err)
 	}
+	test.RetakeCooldown = time.Duration(cooldownSeconds) * time.Second
 	return &test, nil
 }
 
 // GetAnswersByUserTestID получает все ответы пользователя для конкретного теста
 func (r *TestRepository) GetAnswersByUserTestID(ctx context.Context, userTestID int) ([]model.Answer, error) {
 	query := `
-        SELECT id, user_test_id, question_id, user_answer, is_correct, created_at, updated_at
+        SELECT id, user_test_id, question_id, user_answer, is_correct, review_status, review_comment,
+               reviewed_by, reviewed_at, elapsed_seconds, timed_out, created_at, updated_at
         FROM answers
         WHERE user_test_id = $1
         ORDER BY created_at
@@ -567,6 +1458,12 @@ func (r *TestRepository) GetAnswersByUserTestID(ctx context.Context, userTestID
 			&a.QuestionID,
 			&a.UserAnswer,
 			&a.IsCorrect,
+			&a.ReviewStatus,
+			&a.ReviewComment,
+			&a.ReviewedBy,
+			&a.ReviewedAt,
+			&a.ElapsedSeconds,
+			&a.TimedOut,
 			&a.CreatedAt,
 			&a.UpdatedAt,
 		)
@@ -612,6 +1509,8 @@ func (r *TestRepository) GetActiveUserTests(ctx context.Context) ([]model.UserTe
 			&ut.CurrentQuestionIndex,
 			&ut.CorrectAnswersCount,
 			&ut.TimerDeadline,
+			&ut.AttemptOf,
+			&ut.AttemptNumber,
 			&ut.CreatedAt,
 			&ut.UpdatedAt,
 		)
@@ -655,17 +1554,53 @@ func (r *TestRepository) GetUserTestByTestIDAndUserID(ctx context.Context, testI
 	return &userTest, nil
 }
 
+// SaveOptionOrders сохраняет порядок показа вариантов ответа для вопросов назначения
+func (r *TestRepository) SaveOptionOrders(ctx context.Context, userTestID int, orders map[int][]int) error {
+	ordersJSON, err := json.Marshal(orders)
+	if err != nil {
+		return fmt.Errorf("failed to marshal option orders: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, "UPDATE user_tests SET option_orders = $1 WHERE id = $2", ordersJSON, userTestID)
+	if err != nil {
+		return fmt.Errorf("failed to save option orders: %w", err)
+	}
+	return nil
+}
+
+// GetOptionOrders получает порядок показа вариантов ответа по ID вопроса. Возвращает nil, если варианты не перемешивались
+func (r *TestRepository) GetOptionOrders(ctx context.Context, userTestID int) (map[int][]int, error) {
+	var ordersJSON []byte
+	err := r.db.QueryRow(ctx, "SELECT option_orders FROM user_tests WHERE id = $1", userTestID).Scan(&ordersJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("user test %d not found", userTestID)
+		}
+		return nil, fmt.Errorf("failed to get option orders: %w", err)
+	}
+	if ordersJSON == nil {
+		return nil, nil
+	}
+
+	var orders map[int][]int
+	if err := json.Unmarshal(ordersJSON, &orders); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal option orders: %w", err)
+	}
+	return orders, nil
+}
+
 // GetQuestionByID получает вопрос по его ID
 func (r *TestRepository) GetQuestionByID(ctx context.Context, questionID int) (*model.Question, error) {
 	query := `
-        SELECT id, test_id, question_text, answer_type, correct_answer, test_options, created_at, updated_at
+        SELECT id, test_id, question_text, answer_type, correct_answer, test_options,
+               answer_matcher, accepted_answers, numeric_tolerance, time_limit_seconds, created_at, updated_at
         FROM questions
         WHERE id = $1
     `
 	row := r.db.QueryRow(ctx, query, questionID)
 
 	var question model.Question
-	var testOptionsJSON []byte
+	var testOptionsJSON, acceptedAnswersJSON []byte
 	err := row.Scan(
 		&question.ID,
 		&question.TestID,
@@ -673,6 +1608,10 @@ func (r *TestRepository) GetQuestionByID(ctx context.Context, questionID int) (*
 		&question.AnswerType,
 		&question.CorrectAnswer,
 		&testOptionsJSON,
+		&question.AnswerMatcher,
+		&acceptedAnswersJSON,
+		&question.NumericTolerance,
+		&question.TimeLimitSeconds,
 		&question.CreatedAt,
 		&question.UpdatedAt,
 	)
@@ -690,6 +1629,12 @@ func (r *TestRepository) GetQuestionByID(ctx context.Context, questionID int) (*
 			return nil, fmt.Errorf("failed to unmarshal test options: %w", err)
 		}
 	}
+	if acceptedAnswersJSON != nil {
+		err = json.Unmarshal(acceptedAnswersJSON, &question.AcceptedAnswers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal accepted answers: %w", err)
+		}
+	}
 
 	return &question, nil
 }
@@ -750,14 +1695,228 @@ func (r *TestRepository) GetSelectedQuestionIDs(ctx context.Context, userTestID
 	return questionIDs, nil
 }
 
-func (r *TestRepository) SaveTestLink(ctx context.Context, testID int, token string) error {
+// ReserveQuestions выбирает count вопросов теста для назначения userTestID и сохраняет их в selected_question_ids.
+// В первую очередь берутся вопросы, не выбранные ни одним другим тестом в процессе прохождения,
+// затем - наименее занятые. Выбор выполняется в транзакции с блокировкой строки теста,
+// поэтому два одновременных старта одного теста не получат один и тот же набор.
+func (r *TestRepository) ReserveQuestions(ctx context.Context, userTestID int, testID int, count int, answerTypes []string) ([]int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Сериализуем резервирование вопросов в рамках одного теста
+	_, err = tx.Exec(ctx, "SELECT id FROM tests WHERE id = $1 FOR UPDATE", testID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock test %d: %w", testID, err)
+	}
+
+	// Внутренний запрос выбирает наименее занятые вопросы, внешний - перемешивает порядок их показа
 	query := `
-        INSERT INTO test_links (test_id, token, created_at)
-        VALUES ($1, $2, CURRENT_TIMESTAMP)
+        SELECT id FROM (
+            SELECT q.id
+            FROM questions q
+            WHERE q.test_id = $1 AND q.answer_type = ANY($3)
+            ORDER BY (
+                    SELECT COUNT(*)
+                    FROM user_tests ut
+                    WHERE ut.status = 'in_progress'
+                    AND ut.id <> $2
+                    AND q.id = ANY(ut.selected_question_ids)
+                ),
+                random()
+            LIMIT $4
+        ) reserved
+        ORDER BY random()
     `
-	_, err := r.db.Exec(ctx, query, testID, token)
+	rows, err := tx.Query(ctx, query, testID, userTestID, answerTypes, count)
 	if err != nil {
-		return fmt.Errorf("failed to save test link: %w", err)
+		return nil, fmt.Errorf("failed to query free questions: %w", err)
 	}
-	return nil
+
+	var questionIDs []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan question ID: %w", err)
+		}
+		questionIDs = append(questionIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over rows: %w", err)
+	}
+
+	if len(questionIDs) < count {
+		return nil, fmt.Errorf("%w: available %d, required %d", ErrNotEnoughQuestions, len(questionIDs), count)
+	}
+
+	commandTag, err := tx.Exec(ctx, `
+        UPDATE user_tests
+        SET selected_question_ids = $2,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, userTestID, questionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save selected question IDs: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("user test %d not found", userTestID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	result := make([]int, len(questionIDs))
+	for i, id := range questionIDs {
+		result[i] = int(id)
+	}
+	return result, nil
+}
+
+// SaveTestLink сохраняет токен для ссылки на тест, выпущенной пользователем createdBy
+func (r *TestRepository) SaveTestLink(ctx context.Context, testID int, token string, createdBy int, expiresAt *time.Time, maxUses *int, window model.AvailabilityWindow) (int, error) {
+	query := `
+        INSERT INTO test_links (test_id, token, created_by, expires_at, max_uses, available_from, available_until, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        RETURNING id
+    `
+	var linkID int
+	err := r.db.QueryRow(ctx, query, testID, token, createdBy, expiresAt, maxUses, window.AvailableFrom, window.AvailableUntil).Scan(&linkID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save test link: %w", err)
+	}
+	return linkID, nil
+}
+
+// testLinkColumns колонки ссылки на тест вместе с username выпустившего ее HR
+const testLinkColumns = `
+        tl.id, tl.test_id, tl.token, COALESCE(tl.created_by, 0), COALESCE(u.telegram_username, ''),
+        tl.expires_at, tl.max_uses, tl.use_count, tl.revoked_at, tl.created_at,
+        tl.available_from, tl.available_until
+`
+
+// scanTestLink читает ссылку на тест, выбранную с колонками testLinkColumns
+func scanTestLink(row pgx.Row) (*model.TestLink, error) {
+	var link model.TestLink
+	err := row.Scan(
+		&link.ID,
+		&link.TestID,
+		&link.Token,
+		&link.CreatedBy,
+		&link.CreatedByUsername,
+		&link.ExpiresAt,
+		&link.MaxUses,
+		&link.UseCount,
+		&link.RevokedAt,
+		&link.CreatedAt,
+		&link.AvailableFrom,
+		&link.AvailableUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// GetTestLinkByToken получает ссылку на тест по токену, возвращает nil, если ссылка не найдена
+func (r *TestRepository) GetTestLinkByToken(ctx context.Context, token string) (*model.TestLink, error) {
+	query := `
+        SELECT ` + testLinkColumns + `
+        FROM test_links tl
+        LEFT JOIN users u ON u.id = tl.created_by
+        WHERE tl.token = $1
+    `
+	link, err := scanTestLink(r.db.QueryRow(ctx, query, token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get test link: %w", err)
+	}
+	return link, nil
+}
+
+// AssignTestByLink засчитывает использование ссылки и назначает тест пользователю в одной транзакции,
+// поэтому при ошибке назначения использование ссылки не теряется.
+// Возвращает false, если ссылка отозвана, истекла или исчерпала лимит использований.
+func (r *TestRepository) AssignTestByLink(ctx context.Context, linkID int, userID int, testID int, assignedByID int, window model.AvailabilityWindow) (int, bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+        UPDATE test_links
+        SET use_count = use_count + 1, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+          AND revoked_at IS NULL
+          AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+          AND (max_uses IS NULL OR use_count < max_uses)
+    `
+	commandTag, err := tx.Exec(ctx, query, linkID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to use test link: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return 0, false, nil
+	}
+
+	userTestID, err := assignTestToUser(ctx, tx, userID, testID, assignedByID, nil, window)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userTestID, true, nil
+}
+
+// GetTestLinksByCreator получает ссылки, выпущенные пользователем, новые первыми. testID = 0 - ссылки на все тесты
+func (r *TestRepository) GetTestLinksByCreator(ctx context.Context, createdBy int, testID int) ([]model.TestLink, error) {
+	query := `
+        SELECT ` + testLinkColumns + `
+        FROM test_links tl
+        LEFT JOIN users u ON u.id = tl.created_by
+        WHERE tl.created_by = $1 AND ($2 = 0 OR tl.test_id = $2)
+        ORDER BY tl.created_at DESC, tl.id DESC
+    `
+	rows, err := r.db.Query(ctx, query, createdBy, testID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test links: %w", err)
+	}
+	defer rows.Close()
+
+	links := make([]model.TestLink, 0)
+	for rows.Next() {
+		link, err := scanTestLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan test link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate test links: %w", err)
+	}
+	return links, nil
+}
+
+// RevokeTestLink отзывает ссылку, выпущенную пользователем createdBy.
+// Возвращает false, если такой действующей ссылки нет.
+func (r *TestRepository) RevokeTestLink(ctx context.Context, linkID int, createdBy int) (bool, error) {
+	query := `
+        UPDATE test_links
+        SET revoked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND created_by = $2 AND revoked_at IS NULL
+    `
+	commandTag, err := r.db.Exec(ctx, query, linkID, createdBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke test link: %w", err)
+	}
+	return commandTag.RowsAffected() > 0, nil
 }
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ParseAnswerSet декодирует множество ответов, сохраненное JSON-массивом (например, `["A)", "C)"]`).
+// Для совместимости со значениями, заполненными вручную, поддерживается перечисление через запятую.
+func ParseAnswerSet(raw string) []string {
+	var values []string
+	if err := json.Unmarshal([]byte(raw), &values); err == nil {
+		return values
+	}
+
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// CheckMultipleAnswer проверяет ответ на вопрос с несколькими правильными ответами.
+// Возвращает выбранные варианты в виде JSON-массива для сохранения в answers и признак правильности.
+func (s *TestService) CheckMultipleAnswer(question model.Question, optionIndexes []int) (string, bool, error) {
+	selected := make([]string, 0, len(optionIndexes))
+	for _, idx := range optionIndexes {
+		if idx < 0 || idx >= len(question.TestOptions) {
+			return "", false, fmt.Errorf("option index %d out of range for question %d", idx, question.ID)
+		}
+		selected = append(selected, question.TestOptions[idx])
+	}
+
+	userAnswer, err := json.Marshal(selected)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal selected options: %w", err)
+	}
+
+	return string(userAnswer), sameAnswerSet(selected, ParseAnswerSet(question.CorrectAnswer)), nil
+}
+
+// sameAnswerSet сравнивает два множества ответов без учета порядка
+func sameAnswerSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// supportedAnswerTypes типы вопросов, которые бот умеет проводить
+var supportedAnswerTypes = []string{model.AnswerTypeSingle, model.AnswerTypeMultiple, model.AnswerTypeText}
+
+// IsSupportedAnswerType сообщает, умеет ли бот проводить вопросы данного типа
+func IsSupportedAnswerType(answerType string) bool {
+	return slices.Contains(supportedAnswerTypes, answerType)
+}
+
+// answerValues возвращает ответ в виде списка значений: для вопросов с несколькими ответами
+// декодирует множество, для остальных - возвращает единственное значение
+func answerValues(answerType string, raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	if answerType == model.AnswerTypeMultiple {
+		return ParseAnswerSet(raw)
+	}
+	return []string{raw}
+}
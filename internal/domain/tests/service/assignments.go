@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"github.com/IT-Nick/internal/domain/tests/repository"
+)
+
+var (
+	// ErrAssignmentNotFound назначение не найдено, принадлежит другому HR или уже не может быть изменено
+	ErrAssignmentNotFound = errors.New("assignment not found or cannot be changed")
+	// ErrAssignmentNotAvailable назначение нельзя начать: оно уже начато, отменено или просрочено
+	ErrAssignmentNotAvailable = repository.ErrAssignmentNotAvailable
+	// ErrAttemptsExhausted исчерпан лимит попыток прохождения теста
+	ErrAttemptsExhausted = repository.ErrAttemptsExhausted
+	// ErrRetakeCooldown пауза перед повторной попыткой еще не прошла, время доступности - в RetakeCooldownError
+	ErrRetakeCooldown = repository.ErrRetakeCooldown
+	// ErrOutsideAvailabilityWindow тест начинают вне окна доступности, окно - в AvailabilityWindowError
+	ErrOutsideAvailabilityWindow = repository.ErrOutsideAvailabilityWindow
+	// ErrInvalidAvailabilityWindow окно доступности заканчивается не позже, чем начинается
+	ErrInvalidAvailabilityWindow = errors.New("available_until must be after available_from")
+)
+
+// GetAssignmentsByHR получает назначения HR постранично (page с 1), кроме отмененных
+func (s *TestService) GetAssignmentsByHR(ctx context.Context, hrID int, page int, pageSize int) ([]model.Assignment, error) {
+	offset := (page - 1) * pageSize
+	assignments, err := s.testRepo.GetAssignmentsByHR(ctx, hrID, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// CountAssignmentsByHR возвращает количество назначений HR, кроме отмененных
+func (s *TestService) CountAssignmentsByHR(ctx context.Context, hrID int) (int, error) {
+	return s.testRepo.CountAssignmentsByHR(ctx, hrID)
+}
+
+// GetAssignmentForHR получает назначение HR по ID
+func (s *TestService) GetAssignmentForHR(ctx context.Context, userTestID int, hrID int) (*model.Assignment, error) {
+	assignment, err := s.testRepo.GetAssignment(ctx, userTestID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil || assignment.AssignedBy != hrID || assignment.Status == model.UserTestStatusCancelled {
+		return nil, ErrAssignmentNotFound
+	}
+	return assignment, nil
+}
+
+// CancelAssignment отменяет назначение, которое кандидат еще не начал, и возвращает его для уведомления кандидата
+func (s *TestService) CancelAssignment(ctx context.Context, userTestID int, hrID int) (*model.Assignment, error) {
+	assignment, err := s.GetAssignmentForHR(ctx, userTestID, hrID)
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := s.testRepo.CancelAssignment(ctx, userTestID, hrID)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrAssignmentNotFound
+	}
+	assignment.Status = model.UserTestStatusCancelled
+	return assignment, nil
+}
+
+// ReassignTest заменяет тест в назначении, которое кандидат еще не начал
+func (s *TestService) ReassignTest(ctx context.Context, userTestID int, hrID int, testID int) (*model.Assignment, error) {
+	test, err := s.testRepo.GetTestByID(ctx, testID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get test: %w", err)
+	}
+	if test == nil {
+		return nil, fmt.Errorf("test %d not found", testID)
+	}
+
+	reassigned, err := s.testRepo.ReassignTest(ctx, userTestID, hrID, testID)
+	if err != nil {
+		return nil, err
+	}
+	if !reassigned {
+		return nil, ErrAssignmentNotFound
+	}
+	return s.GetAssignmentForHR(ctx, userTestID, hrID)
+}
+
+// TransferAssignment передает назначение другому HR
+func (s *TestService) TransferAssignment(ctx context.Context, userTestID int, hrID int, newHRID int) (*model.Assignment, error) {
+	transferred, err := s.testRepo.TransferAssignment(ctx, userTestID, hrID, newHRID)
+	if err != nil {
+		return nil, err
+	}
+	if !transferred {
+		return nil, ErrAssignmentNotFound
+	}
+	return s.GetAssignmentForHR(ctx, userTestID, newHRID)
+}
+
+// GetAvailableAssignmentsForUser получает назначения, которые пользователь может начать
+func (s *TestService) GetAvailableAssignmentsForUser(ctx context.Context, username string) ([]model.Assignment, error) {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+
+	assignments, err := s.testRepo.GetAvailableAssignmentsForUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// StartAssignment начинает попытку по выбранному пользователем назначению и возвращает ID попытки (user_test_id)
+func (s *TestService) StartAssignment(ctx context.Context, username string, userTestID int) (int, error) {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return 0, fmt.Errorf("user %s not found", username)
+	}
+	return s.testRepo.StartAttempt(ctx, user.ID, userTestID)
+}
+
+// AbortAttempt отменяет попытку, начатую StartAssignment, если подготовить ее не удалось
+func (s *TestService) AbortAttempt(ctx context.Context, userTestID int) error {
+	return s.testRepo.AbortAttempt(ctx, userTestID)
+}
+
+// ValidateAvailabilityWindow проверяет, что окно доступности не пустое
+func ValidateAvailabilityWindow(window model.AvailabilityWindow) error {
+	if window.AvailableFrom != nil && window.AvailableUntil != nil && !window.AvailableUntil.After(*window.AvailableFrom) {
+		return ErrInvalidAvailabilityWindow
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxBulkAssignments максимальное количество пользователей в одном массовом назначении
+const MaxBulkAssignments = 500
+
+// ErrTooManyUsernames возвращается, если список для массового назначения длиннее MaxBulkAssignments
+var ErrTooManyUsernames = fmt.Errorf("too many usernames, at most %d are allowed", MaxBulkAssignments)
+
+// usernameRx допустимый формат username в Telegram
+var usernameRx = regexp.MustCompile(`^[A-Za-z0-9_]{4,32}$`)
+
+// usernameSeparators разделители username в списке: перевод строки, запятая, точка с запятой и пробелы
+var usernameSeparators = regexp.MustCompile(`[\s,;]+`)
+
+// csvHeaders названия колонки с username, которые пропускаются в первой строке CSV
+var csvHeaders = []string{"username", "telegram_username", "telegram", "login"}
+
+// ParseUsernameList разбирает список username, разделенных переводами строк, запятыми или пробелами
+func ParseUsernameList(text string) []string {
+	var usernames []string
+	for _, field := range usernameSeparators.Split(text, -1) {
+		if field != "" {
+			usernames = append(usernames, field)
+		}
+	}
+	return usernames
+}
+
+// ParseUsernameCSV читает username из первой колонки CSV файла. Строка заголовка пропускается.
+func ParseUsernameCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var usernames []string
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+		if len(record) == 0 {
+			continue
+		}
+		value := strings.TrimSpace(record[0])
+		if value == "" {
+			continue
+		}
+		if line == 0 && isCSVHeader(value) {
+			continue
+		}
+		usernames = append(usernames, value)
+	}
+	return usernames, nil
+}
+
+// BulkAssignTest назначает тест списку пользователей от имени assignedByUsername.
+// Некорректные и повторяющиеся в списке username не назначаются, остальные назначаются в одной транзакции.
+// Результаты возвращаются в порядке списка. deadline - срок, до которого тест нужно начать, nil - без срока.
+func (s *TestService) BulkAssignTest(ctx context.Context, testID int, usernames []string, assignedByUsername string, deadline *time.Time, window model.AvailabilityWindow) ([]model.BulkAssignmentResult, error) {
+	if len(usernames) > MaxBulkAssignments {
+		return nil, ErrTooManyUsernames
+	}
+	if err := ValidateAvailabilityWindow(window); err != nil {
+		return nil, err
+	}
+
+	assignedBy, err := s.userRepo.GetUserByUsername(ctx, assignedByUsername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assigning user: %w", err)
+	}
+	if assignedBy == nil {
+		return nil, fmt.Errorf("assigning user %s not found", assignedByUsername)
+	}
+
+	results := make([]model.BulkAssignmentResult, len(usernames))
+	seen := make(map[string]bool, len(usernames))
+	var valid []string
+	var validIndexes []int
+	for i, raw := range usernames {
+		username := strings.TrimPrefix(strings.TrimSpace(raw), "@")
+		results[i].Username = username
+		switch {
+		case !usernameRx.MatchString(username):
+			results[i].Username = raw
+			results[i].Status = model.BulkAssignInvalid
+		case seen[strings.ToLower(username)]:
+			results[i].Status = model.BulkAssignDuplicate
+		default:
+			seen[strings.ToLower(username)] = true
+			valid = append(valid, username)
+			validIndexes = append(validIndexes, i)
+		}
+	}
+
+	if len(valid) > 0 {
+		assigned, err := s.testRepo.BulkAssignTest(ctx, testID, assignedBy.ID, valid, deadline, window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bulk assign test: %w", err)
+		}
+		for i, result := range assigned {
+			results[validIndexes[i]] = result
+		}
+	}
+	return results, nil
+}
+
+// CountBulkResults подсчитывает результаты массового назначения по статусам
+func CountBulkResults(results []model.BulkAssignmentResult) map[string]int {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	return counts
+}
+
+// isCSVHeader сообщает, что значение является заголовком колонки, а не username
+func isCSVHeader(value string) bool {
+	for _, header := range csvHeaders {
+		if strings.EqualFold(value, header) {
+			return true
+		}
+	}
+	return false
+}
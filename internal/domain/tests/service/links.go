@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"time"
+)
+
+var (
+	// ErrTestLinkNotFound ссылка не найдена или ведет на другой тест
+	ErrTestLinkNotFound = errors.New("test link not found")
+	// ErrTestLinkRevoked ссылка отозвана
+	ErrTestLinkRevoked = errors.New("test link revoked")
+	// ErrTestLinkExpired срок действия ссылки истек
+	ErrTestLinkExpired = errors.New("test link expired")
+	// ErrTestLinkExhausted исчерпан лимит использований ссылки
+	ErrTestLinkExhausted = errors.New("test link usage limit reached")
+)
+
+// SaveTestLink сохраняет токен для ссылки на тест, выпущенной пользователем createdBy.
+// expiresAt и maxUses необязательны: nil означает ссылку без срока действия и без лимита использований.
+// window - окно доступности, которое получат назначения по ссылке.
+func (s *TestService) SaveTestLink(ctx context.Context, testID int, token string, createdBy int, expiresAt *time.Time, maxUses *int, window model.AvailabilityWindow) (int, error) {
+	if err := ValidateAvailabilityWindow(window); err != nil {
+		return 0, err
+	}
+	return s.testRepo.SaveTestLink(ctx, testID, token, createdBy, expiresAt, maxUses, window)
+}
+
+// ValidateTestLink проверяет ссылку на тест и возвращает ее вместе с выпустившим ее HR.
+// HR определяется по записи в test_links, а не по параметрам ссылки, поэтому подделать автора нельзя.
+func (s *TestService) ValidateTestLink(ctx context.Context, testID int, token string) (*model.TestLink, error) {
+	link, err := s.GetActiveTestLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link.TestID != testID {
+		return nil, ErrTestLinkNotFound
+	}
+	return link, nil
+}
+
+// GetActiveTestLink возвращает действующую ссылку по токену
+func (s *TestService) GetActiveTestLink(ctx context.Context, token string) (*model.TestLink, error) {
+	link, err := s.testRepo.GetTestLinkByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.CreatedBy == 0 {
+		return nil, ErrTestLinkNotFound
+	}
+	if link.RevokedAt != nil {
+		return nil, ErrTestLinkRevoked
+	}
+	if link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt) {
+		return nil, ErrTestLinkExpired
+	}
+	if link.MaxUses != nil && link.UseCount >= *link.MaxUses {
+		return nil, ErrTestLinkExhausted
+	}
+	return link, nil
+}
+
+// TestDeepLink формирует ссылку на запуск бота с назначением теста по токену
+func TestDeepLink(botUsername string, testID int, token string) string {
+	return fmt.Sprintf("https://t.me/%s?start=test_%d_%s", botUsername, testID, token)
+}
+
+// AssignTestByLink назначает тест по ссылке пользователю и засчитывает использование ссылки.
+// Назначение создает выпустивший ссылку HR, окно доступности берется из ссылки.
+// Возвращает ErrTestLinkExhausted, если ссылка стала недействительной после проверки.
+func (s *TestService) AssignTestByLink(ctx context.Context, link *model.TestLink, userID int) (int, error) {
+	if err := ValidateAvailabilityWindow(link.AvailabilityWindow); err != nil {
+		return 0, err
+	}
+
+	userTestID, used, err := s.testRepo.AssignTestByLink(ctx, link.ID, userID, link.TestID, link.CreatedBy, link.AvailabilityWindow)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign test by link: %w", err)
+	}
+	if !used {
+		return 0, ErrTestLinkExhausted
+	}
+	return userTestID, nil
+}
+
+// GetTestLinksByCreator возвращает ссылки, выпущенные пользователем. testID = 0 - ссылки на все тесты
+func (s *TestService) GetTestLinksByCreator(ctx context.Context, createdBy int, testID int) ([]model.TestLink, error) {
+	return s.testRepo.GetTestLinksByCreator(ctx, createdBy, testID)
+}
+
+// RevokeTestLink отзывает ссылку, выпущенную пользователем createdBy
+func (s *TestService) RevokeTestLink(ctx context.Context, linkID int, createdBy int) error {
+	revoked, err := s.testRepo.RevokeTestLink(ctx, linkID, createdBy)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("%w: link %d", ErrTestLinkNotFound, linkID)
+	}
+	return nil
+}
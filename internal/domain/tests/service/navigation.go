@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+)
+
+// IsFreeNavigation сообщает, можно ли в тесте назначения пропускать вопросы и менять ответы до отправки
+func (s *TestService) IsFreeNavigation(ctx context.Context, userTestID int) (bool, error) {
+	freeNavigation, err := s.testRepo.IsFreeNavigation(ctx, userTestID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get navigation mode: %w", err)
+	}
+	return freeNavigation, nil
+}
+
+// ReplaceAnswer сохраняет ответ в режиме свободной навигации: предыдущий ответ на этот вопрос заменяется.
+// Баллы не начисляются, correct_answers_count пересчитывается один раз при отправке теста (FinishUserTest).
+func (s *TestService) ReplaceAnswer(ctx context.Context, userTestID int, questionID int, userAnswer string, isCorrect bool, needsReview bool) error {
+	reviewStatus := model.ReviewStatusAuto
+	if needsReview {
+		reviewStatus = model.ReviewStatusPending
+		isCorrect = false
+	}
+
+	err := s.testRepo.ReplaceAnswer(ctx, userTestID, questionID, userAnswer, isCorrect, reviewStatus)
+	if err != nil {
+		return fmt.Errorf("failed to replace answer: %w", err)
+	}
+	return nil
+}
+
+// UpdateCurrentQuestion запоминает вопрос, который кандидат открыл в режиме свободной навигации
+func (s *TestService) UpdateCurrentQuestion(ctx context.Context, userTestID int, currentQuestionIndex int) error {
+	err := s.testRepo.UpdateUserTestQuestionIndex(ctx, userTestID, currentQuestionIndex)
+	if err != nil {
+		return fmt.Errorf("failed to update current question: %w", err)
+	}
+	return nil
+}
+
+// GetAnswersByQuestion получает ответы кандидата по ID вопросов
+func (s *TestService) GetAnswersByQuestion(ctx context.Context, userTestID int) (map[int]model.Answer, error) {
+	answers, err := s.testRepo.GetAnswersByUserTestID(ctx, userTestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get answers: %w", err)
+	}
+
+	byQuestion := make(map[int]model.Answer, len(answers))
+	for _, answer := range answers {
+		byQuestion[answer.QuestionID] = answer
+	}
+	return byQuestion, nil
+}
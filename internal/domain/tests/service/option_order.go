@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"math/rand/v2"
+)
+
+// shuffleOptions генерирует случайный порядок показа вариантов для вопросов с выбором ответа.
+// Возвращает порядки по ID вопроса для сохранения в user_tests.option_orders.
+func shuffleOptions(questions []model.Question) map[int][]int {
+	orders := make(map[int][]int)
+	for i := range questions {
+		question := &questions[i]
+		if question.AnswerType == model.AnswerTypeText || len(question.TestOptions) < 2 {
+			continue
+		}
+		question.OptionOrder = rand.Perm(len(question.TestOptions))
+		orders[question.ID] = question.OptionOrder
+	}
+	return orders
+}
+
+// applyOptionOrders задает вопросам сохраненный порядок показа вариантов.
+// Порядок, не соответствующий вариантам вопроса (например, после их изменения), не применяется.
+func applyOptionOrders(questions []model.Question, orders map[int][]int) {
+	for i := range questions {
+		order, ok := orders[questions[i].ID]
+		if ok && isPermutation(order, len(questions[i].TestOptions)) {
+			questions[i].OptionOrder = order
+		}
+	}
+}
+
+// isPermutation проверяет, что order содержит каждый индекс от 0 до n-1 ровно один раз
+func isPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+	seen := make([]bool, n)
+	for _, idx := range order {
+		if idx < 0 || idx >= n || seen[idx] {
+			return false
+		}
+		seen[idx] = true
+	}
+	return true
+}
+
+// GetSelectedQuestion получает вопрос назначения с порядком показа вариантов, выбранным для кандидата
+func (s *TestService) GetSelectedQuestion(ctx context.Context, userTestID int, questionID int) (*model.Question, error) {
+	question, err := s.testRepo.GetQuestionByID(ctx, questionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get question: %w", err)
+	}
+	if question == nil {
+		return nil, fmt.Errorf("question %d not found", questionID)
+	}
+
+	orders, err := s.testRepo.GetOptionOrders(ctx, userTestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get option orders: %w", err)
+	}
+
+	questions := []model.Question{*question}
+	applyOptionOrders(questions, orders)
+	return &questions[0], nil
+}
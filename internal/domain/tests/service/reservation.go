@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+)
+
+// ReserveQuestions формирует набор вопросов для начатого теста.
+// Кандидаты, проходящие тест одновременно, получают непересекающиеся наборы; вопросы,
+// уже выбранные другими кандидатами, используются только когда свободных не хватает.
+// Выбранные ID сохраняются в user_tests.selected_question_ids, вопросы возвращаются в случайном порядке.
+// Если в тесте включено перемешивание вариантов, для кандидата сохраняется свой порядок вариантов каждого вопроса.
+func (s *TestService) ReserveQuestions(ctx context.Context, userTestID int, test *model.Test) ([]model.Question, error) {
+	if test.QuestionCount <= 0 {
+		return nil, fmt.Errorf("invalid question count %d for test %d", test.QuestionCount, test.ID)
+	}
+
+	questionIDs, err := s.testRepo.ReserveQuestions(ctx, userTestID, test.ID, test.QuestionCount, supportedAnswerTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve questions: %w", err)
+	}
+
+	questions := make([]model.Question, 0, len(questionIDs))
+	for _, id := range questionIDs {
+		question, err := s.testRepo.GetQuestionByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get question %d: %w", id, err)
+		}
+		if question == nil {
+			return nil, fmt.Errorf("question %d not found", id)
+		}
+		questions = append(questions, *question)
+	}
+
+	if test.ShuffleOptions {
+		err = s.testRepo.SaveOptionOrders(ctx, userTestID, shuffleOptions(questions))
+		if err != nil {
+			return nil, fmt.Errorf("failed to save option orders: %w", err)
+		}
+	}
+	return questions, nil
+}
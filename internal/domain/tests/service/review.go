@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"time"
+)
+
+// NeedsManualReview сообщает, что ответ на вопрос проверяет менеджер, а не бот
+func NeedsManualReview(question model.Question) bool {
+	return question.AnswerType == model.AnswerTypeText && question.AnswerMatcher == model.MatcherManual
+}
+
+// FinishUserTest завершает прохождение теста и возвращает итоговый статус:
+// finished или pending_review, если часть ответов ожидает ручной проверки.
+// Пустой статус означает, что тест уже был завершен ранее.
+func (s *TestService) FinishUserTest(ctx context.Context, userTestID int) (string, error) {
+	status, err := s.testRepo.FinishUserTest(ctx, userTestID, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to finish user test: %w", err)
+	}
+	return status, nil
+}
+
+// GetNextPendingReview получает следующий ответ для ручной проверки и общее количество непроверенных ответов
+func (s *TestService) GetNextPendingReview(ctx context.Context) (*model.PendingReview, int, error) {
+	review, err := s.testRepo.GetNextPendingReview(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get next pending review: %w", err)
+	}
+	if review == nil {
+		return nil, 0, nil
+	}
+
+	count, err := s.testRepo.CountPendingReviews(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count pending reviews: %w", err)
+	}
+	return review, count, nil
+}
+
+// ReviewAnswer сохраняет оценку ответа менеджером.
+// Возвращает ID назначения теста и признак того, что проверка всего теста завершена и балл пересчитан.
+func (s *TestService) ReviewAnswer(ctx context.Context, answerID int, reviewerUsername string, isCorrect bool) (int, bool, error) {
+	reviewer, err := s.userRepo.GetUserByUsername(ctx, reviewerUsername)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get reviewer: %w", err)
+	}
+	if reviewer == nil {
+		return 0, false, fmt.Errorf("reviewer %s not found", reviewerUsername)
+	}
+
+	userTestID, completed, err := s.testRepo.ReviewAnswer(ctx, answerID, reviewer.ID, isCorrect)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to review answer: %w", err)
+	}
+	return userTestID, completed, nil
+}
+
+// SaveReviewComment сохраняет комментарий менеджера к проверенному ответу
+func (s *TestService) SaveReviewComment(ctx context.Context, answerID int, comment string) error {
+	err := s.testRepo.SaveReviewComment(ctx, answerID, comment)
+	if err != nil {
+		return fmt.Errorf("failed to save review comment: %w", err)
+	}
+	return nil
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/IT-Nick/internal/domain/model"
 	"github.com/IT-Nick/internal/domain/tests/repository"
 	usersRepo "github.com/IT-Nick/internal/domain/users/repository"
-	"log"
 	"time"
 )
 
@@ -43,8 +42,11 @@ func (s *TestService) GetTotalTestsCount(ctx context.Context) (int, error) {
 	return count, nil
 }
 
-// AssignTestToUser назначает тест существующему пользователю
-func (s *TestService) AssignTestToUser(ctx context.Context, userID int, testID int, assignedByUsername string) (int, error) {
+// AssignTestToUser назначает тест существующему пользователю. deadline - срок, до которого тест нужно начать, nil - без срока
+func (s *TestService) AssignTestToUser(ctx context.Context, userID int, testID int, assignedByUsername string, deadline *time.Time, window model.AvailabilityWindow) (int, error) {
+	if err := ValidateAvailabilityWindow(window); err != nil {
+		return 0, err
+	}
 	assignedBy, err := s.userRepo.GetUserByUsername(ctx, assignedByUsername)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get assigning user: %w", err)
@@ -53,15 +55,35 @@ func (s *TestService) AssignTestToUser(ctx context.Context, userID int, testID i
 		return 0, fmt.Errorf("assigning user %s not found", assignedByUsername)
 	}
 
-	userTestID, err := s.testRepo.AssignTestToUser(ctx, userID, testID, assignedBy.ID)
+	userTestID, err := s.testRepo.AssignTestToUser(ctx, userID, testID, assignedBy.ID, deadline, window)
 	if err != nil {
 		return 0, fmt.Errorf("failed to assign test: %w", err)
 	}
 	return userTestID, nil
 }
 
-// AssignPendingTest создает отложенное назначение теста
-func (s *TestService) AssignPendingTest(ctx context.Context, telegramUsername string, testID int, assignedByUsername string) (int, error) {
+// ExpireOverdueAssignments переводит назначения с истекшим сроком в статус expired
+func (s *TestService) ExpireOverdueAssignments(ctx context.Context) ([]model.ExpiredAssignment, error) {
+	return s.testRepo.ExpireOverdueAssignments(ctx)
+}
+
+// ClaimDeadlineReminders возвращает назначения, которым пора отправить напоминание за before до срока,
+// и отмечает напоминание отправленным. notAfter - окно следующего напоминания, в котором это уже не отправляется
+func (s *TestService) ClaimDeadlineReminders(ctx context.Context, reminderKey string, before, notAfter time.Duration) ([]model.Reminder, error) {
+	return s.testRepo.ClaimDeadlineReminders(ctx, reminderKey, before, notAfter)
+}
+
+// ClaimAssignmentReminders возвращает назначения, которым пора отправить напоминание через after после назначения,
+// и отмечает напоминание отправленным
+func (s *TestService) ClaimAssignmentReminders(ctx context.Context, reminderKey string, after time.Duration) ([]model.Reminder, error) {
+	return s.testRepo.ClaimAssignmentReminders(ctx, reminderKey, after)
+}
+
+// AssignPendingTest создает отложенное назначение теста. deadline - срок, до которого тест нужно начать, nil - без срока
+func (s *TestService) AssignPendingTest(ctx context.Context, telegramUsername string, testID int, assignedByUsername string, deadline *time.Time, window model.AvailabilityWindow) (int, error) {
+	if err := ValidateAvailabilityWindow(window); err != nil {
+		return 0, err
+	}
 	assignedBy, err := s.userRepo.GetUserByUsername(ctx, assignedByUsername)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get assigning user: %w", err)
@@ -70,7 +92,7 @@ func (s *TestService) AssignPendingTest(ctx context.Context, telegramUsername st
 		return 0, fmt.Errorf("assigning user %s not found", assignedByUsername)
 	}
 
-	userTestID, err := s.testRepo.AssignPendingTest(ctx, telegramUsername, testID, assignedBy.ID)
+	userTestID, err := s.testRepo.AssignPendingTest(ctx, telegramUsername, testID, assignedBy.ID, deadline, window)
 	if err != nil {
 		return 0, fmt.Errorf("failed to assign pending test: %w", err)
 	}
@@ -98,67 +120,6 @@ func (s *TestService) GetLastTestForUserWithFinishStatus(ctx context.Context, us
 	return test, nil
 }
 
-// GetAvailableTestsForUser получает список доступных тестов для пользователя
-func (s *TestService) GetAvailableTestsForUser(ctx context.Context, username string) ([]model.Test, error) {
-	// Получаем пользователя по username
-	user, err := s.userRepo.GetUserByUsername(ctx, username)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return nil, fmt.Errorf("user %s not found", username)
-	}
-
-	// Получаем доступные тесты для пользователя
-	tests, err := s.testRepo.GetAvailableTestsForUser(ctx, user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get available tests: %w", err)
-	}
-
-	return tests, nil
-}
-
-// StartTestForUser начинает тест для пользователя и возвращает ID назначения теста (user_test_id)
-func (s *TestService) StartTestForUser(ctx context.Context, username string, testID int) (int, error) {
-	// Получаем пользователя по username
-	user, err := s.userRepo.GetUserByUsername(ctx, username)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return 0, fmt.Errorf("user %s not found", username)
-	}
-
-	// Проверяем, назначен ли тест пользователю
-	isAssigned, err := s.testRepo.CheckTestAssignment(ctx, user.ID, testID)
-	if err != nil {
-		return 0, fmt.Errorf("failed to check test assignment: %w", err)
-	}
-	if !isAssigned {
-		return 0, fmt.Errorf("test %d is not assigned to user %d", testID, user.ID)
-	}
-
-	// Получаем тест для проверки duration
-	test, err := s.testRepo.GetTestByID(ctx, testID)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get test: %w", err)
-	}
-
-	// Проверяем duration теста
-	if test.Duration <= 0 {
-		return 0, fmt.Errorf("invalid test duration: %d minutes", test.Duration)
-	}
-	log.Printf("Starting test %d for user %d with duration %d minutes", testID, user.ID, test.Duration)
-
-	// Начинаем тест и получаем user_test_id
-	userTestID, err := s.testRepo.StartTest(ctx, user.ID, testID)
-	if err != nil {
-		return 0, fmt.Errorf("failed to start test: %w", err)
-	}
-
-	return userTestID, nil
-}
-
 // ProcessPendingTests обрабатывает отложенные тесты для нового пользователя
 func (s *TestService) ProcessPendingTests(ctx context.Context, userID int, telegramUsername string) error {
 	// Получаем отложенные тесты
@@ -201,13 +162,21 @@ func (s *TestService) UpdateUserTestState(ctx context.Context, userTestID int, c
 	return nil
 }
 
-// SaveAnswer сохраняет ответ пользователя в таблицу answers
-func (s *TestService) SaveAnswer(ctx context.Context, userTestID int, questionID int, userAnswer string, isCorrect bool) error {
-	err := s.testRepo.SaveAnswer(ctx, userTestID, questionID, userAnswer, isCorrect)
+// SaveAnswerAndAdvance сохраняет ответ на вопрос questionIndex и переводит кандидата к следующему вопросу.
+// needsReview - ответ будет оценен менеджером вручную и пока не учитывается в correct_answers_count.
+// Возвращает false, если ответ опоздал: время на вопрос истекло или кандидат уже ответил на него.
+func (s *TestService) SaveAnswerAndAdvance(ctx context.Context, userTestID int, questionIndex int, questionID int, userAnswer string, isCorrect bool, needsReview bool) (bool, error) {
+	reviewStatus := model.ReviewStatusAuto
+	if needsReview {
+		reviewStatus = model.ReviewStatusPending
+		isCorrect = false
+	}
+
+	saved, err := s.testRepo.SaveAnswerAndAdvance(ctx, userTestID, questionIndex, questionID, userAnswer, isCorrect, reviewStatus)
 	if err != nil {
-		return fmt.Errorf("failed to save answer: %w", err)
+		return false, fmt.Errorf("failed to save answer: %w", err)
 	}
-	return nil
+	return saved, nil
 }
 
 // UpdateUserTestStatus обновляет статус теста в таблице user_tests
@@ -236,6 +205,24 @@ func (s *TestService) GetUserTestState(ctx context.Context, userTestID int) (int
 	return currentQuestionIndex, correctAnswersCount, status, nil
 }
 
+// GetActiveTimers получает таймеры всех тестов в процессе прохождения
+func (s *TestService) GetActiveTimers(ctx context.Context) ([]model.ActiveTimer, error) {
+	timers, err := s.testRepo.GetActiveTimers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active timers: %w", err)
+	}
+	return timers, nil
+}
+
+// GetUserTestsProgress получает текущий вопрос и статус для нескольких назначений
+func (s *TestService) GetUserTestsProgress(ctx context.Context, userTestIDs []int) (map[int]model.UserTestProgress, error) {
+	progress, err := s.testRepo.GetUserTestsProgress(ctx, userTestIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user tests progress: %w", err)
+	}
+	return progress, nil
+}
+
 // GetUserTestIDByUserID получает ID текущего теста пользователя из таблицы user_tests
 func (s *TestService) GetUserTestIDByUserID(ctx context.Context, userID int64) (int, error) {
 	userTestID, err := s.testRepo.GetUserTestIDByUserID(ctx, userID)
@@ -254,7 +241,7 @@ func (s *TestService) GetTestIDByUserTestID(ctx context.Context, userTestID int)
 	return testID, nil
 }
 
-// GetUserTestReport получает полный отчет по тестам пользователя
+// GetUserTestReport получает полный отчет по тестам пользователя, каждая попытка прохождения - отдельная запись
 func (s *TestService) GetUserTestReport(ctx context.Context, userID int) ([]dto.TestHistory, error) {
 	// Получаем все тесты пользователя
 	userTests, err := s.testRepo.GetUserTestsByUserID(ctx, userID)
@@ -298,6 +285,9 @@ func (s *TestService) GetUserTestReport(ctx context.Context, userID int) ([]dto.
 			var userAnswer string
 			var isCorrect bool
 			var answeredAt string
+			var reviewStatus, reviewComment string
+			var elapsedSeconds *int
+			var timedOut bool
 
 			// Проверяем, есть ли ответ для этого вопроса
 			for _, a := range answers {
@@ -305,6 +295,12 @@ func (s *TestService) GetUserTestReport(ctx context.Context, userID int) ([]dto.
 					userAnswer = a.UserAnswer
 					isCorrect = a.IsCorrect
 					answeredAt = a.CreatedAt.String()
+					reviewStatus = a.ReviewStatus
+					if a.ReviewComment != nil {
+						reviewComment = *a.ReviewComment
+					}
+					elapsedSeconds = a.ElapsedSeconds
+					timedOut = a.TimedOut
 					break
 				}
 			}
@@ -315,16 +311,29 @@ func (s *TestService) GetUserTestReport(ctx context.Context, userID int) ([]dto.
 				testOptions = q.TestOptions
 			}
 
-			questionInfos = append(questionInfos, dto.QuestionInfo{
+			questionInfo := dto.QuestionInfo{
 				QuestionID:    q.ID,
 				QuestionText:  q.QuestionText,
 				AnswerType:    q.AnswerType,
 				CorrectAnswer: q.CorrectAnswer,
 				TestOptions:   testOptions,
+				OptionOrder:   q.OptionOrder,
 				UserAnswer:    userAnswer,
 				IsCorrect:     isCorrect,
+				ReviewStatus:  reviewStatus,
+				ReviewComment: reviewComment,
 				AnsweredAt:    answeredAt,
-			})
+
+				TimeLimitSeconds: q.TimeLimitSeconds,
+				ElapsedSeconds:   elapsedSeconds,
+				TimedOut:         timedOut,
+			}
+			// Для вопросов с несколькими ответами раскрываем множества выбранных и правильных вариантов
+			if q.AnswerType == model.AnswerTypeMultiple {
+				questionInfo.CorrectAnswers = ParseAnswerSet(q.CorrectAnswer)
+				questionInfo.UserAnswers = answerValues(q.AnswerType, userAnswer)
+			}
+			questionInfos = append(questionInfos, questionInfo)
 		}
 
 		// Проверяем указатели в модели UserTest
@@ -341,8 +350,17 @@ func (s *TestService) GetUserTestReport(ctx context.Context, userID int) ([]dto.
 
 		timerDeadline := userTest.TimerDeadline.String()
 
+		// Повторные попытки ссылаются на исходное назначение
+		assignmentID := userTest.ID
+		if userTest.AttemptOf != nil {
+			assignmentID = *userTest.AttemptOf
+		}
+
 		testHistory = append(testHistory, dto.TestHistory{
 			UserTestID:     userTest.ID,
+			AssignmentID:   assignmentID,
+			AttemptNumber:  userTest.AttemptNumber,
+			MaxAttempts:    test.MaxAttempts,
 			TestID:         test.ID,
 			TestName:       test.TestName,
 			TestType:       test.TestType,
@@ -387,29 +405,22 @@ func (s *TestService) GetActiveTests(ctx context.Context) ([]dto.ActiveTestInfo,
 			return nil, fmt.Errorf("failed to get test %d: %w", userTest.TestID, err)
 		}
 
-		// Получаем вопросы теста
-		questions, err := s.testRepo.GetQuestionsByTestID(ctx, userTest.TestID)
+		// Получаем вопросы, выбранные для кандидата
+		selectedQuestions, err := s.GetSelectedQuestions(ctx, userTest.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get questions for test %d: %w", userTest.TestID, err)
-		}
-
-		// Фильтруем вопросы типа "single"
-		var singleQuestions []model.Question
-		for _, q := range questions {
-			if q.AnswerType == "single" {
-				singleQuestions = append(singleQuestions, q)
-			}
+			return nil, fmt.Errorf("failed to get selected questions for user test %d: %w", userTest.ID, err)
 		}
 
 		// Получаем текущий вопрос
 		var currentQuestion dto.QuestionInfoActive
-		if userTest.CurrentQuestionIndex >= 0 && userTest.CurrentQuestionIndex < len(singleQuestions) {
-			q := singleQuestions[userTest.CurrentQuestionIndex]
+		if userTest.CurrentQuestionIndex >= 0 && userTest.CurrentQuestionIndex < len(selectedQuestions) {
+			q := selectedQuestions[userTest.CurrentQuestionIndex]
 			currentQuestion = dto.QuestionInfoActive{
 				QuestionID:   q.ID,
 				QuestionText: q.QuestionText,
 				AnswerType:   q.AnswerType,
 				TestOptions:  q.TestOptions,
+				OptionOrder:  q.OptionOrder,
 			}
 		}
 
@@ -421,20 +432,30 @@ func (s *TestService) GetActiveTests(ctx context.Context) ([]dto.ActiveTestInfo,
 
 		var previousAnswers []dto.AnswerInfo
 		for _, a := range answers {
-			var questionText string
-			for _, q := range questions {
+			var questionText, answerType string
+			for _, q := range selectedQuestions {
 				if q.ID == a.QuestionID {
 					questionText = q.QuestionText
+					answerType = q.AnswerType
 					break
 				}
 			}
-			previousAnswers = append(previousAnswers, dto.AnswerInfo{
+			answerInfo := dto.AnswerInfo{
 				QuestionID:   a.QuestionID,
 				QuestionText: questionText,
+				AnswerType:   answerType,
 				UserAnswer:   a.UserAnswer,
 				IsCorrect:    a.IsCorrect,
+				ReviewStatus: a.ReviewStatus,
 				AnsweredAt:   a.CreatedAt.String(),
-			})
+
+				ElapsedSeconds: a.ElapsedSeconds,
+				TimedOut:       a.TimedOut,
+			}
+			if answerType == model.AnswerTypeMultiple {
+				answerInfo.UserAnswers = answerValues(answerType, a.UserAnswer)
+			}
+			previousAnswers = append(previousAnswers, answerInfo)
 		}
 
 		// Вычисляем оставшееся время
@@ -446,7 +467,7 @@ func (s *TestService) GetActiveTests(ctx context.Context) ([]dto.ActiveTestInfo,
 			remainingTime = fmt.Sprintf("%02d:%02d", minutes, seconds)
 		}
 
-		fullName := fmt.Sprintf("%s %s %s", user.RealFirstName, user.RealSecondName, user.RealSurname)
+		fullName := user.FullName()
 		activeTestInfos = append(activeTestInfos, dto.ActiveTestInfo{
 			TelegramUsername: user.TelegramUsername,
 			FullName:         fullName,
@@ -457,7 +478,7 @@ func (s *TestService) GetActiveTests(ctx context.Context) ([]dto.ActiveTestInfo,
 			CurrentQuestion:  currentQuestion,
 			PreviousAnswers:  previousAnswers,
 			CorrectAnswers:   userTest.CorrectAnswersCount,
-			TotalQuestions:   len(singleQuestions),
+			TotalQuestions:   len(selectedQuestions),
 			RemainingTime:    remainingTime,
 			Status:           userTest.Status,
 		})
@@ -494,7 +515,7 @@ func (s *TestService) SaveSelectedQuestions(ctx context.Context, userTestID int,
 	return s.testRepo.SaveSelectedQuestions(ctx, userTestID, questionIDs)
 }
 
-// GetSelectedQuestions получает выбранные вопросы для теста
+// GetSelectedQuestions получает выбранные вопросы для теста с порядком показа вариантов, выбранным для кандидата
 func (s *TestService) GetSelectedQuestions(ctx context.Context, userTestID int) ([]model.Question, error) {
 	questionIDs, err := s.testRepo.GetSelectedQuestionIDs(ctx, userTestID)
 	if err != nil {
@@ -508,12 +529,13 @@ func (s *TestService) GetSelectedQuestions(ctx context.Context, userTestID int)
 		}
 		questions = append(questions, *question)
 	}
-	return questions, nil
-}
 
-// SaveTestLink сохраняет токен для ссылки на тест
-func (s *TestService) SaveTestLink(ctx context.Context, testID int, token string) error {
-	return s.testRepo.SaveTestLink(ctx, testID, token)
+	orders, err := s.testRepo.GetOptionOrders(ctx, userTestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get option orders: %w", err)
+	}
+	applyOptionOrders(questions, orders)
+	return questions, nil
 }
 
 // GetTestByID сохраняет токен для ссылки на тест
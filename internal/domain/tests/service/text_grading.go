@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AnswerMatcher проверяет свободный ответ кандидата на вопрос с типом "text"
+type AnswerMatcher interface {
+	Match(question model.Question, answer string) (bool, error)
+}
+
+// AnswerMatcherFunc позволяет использовать обычную функцию как AnswerMatcher
+type AnswerMatcherFunc func(question model.Question, answer string) (bool, error)
+
+// Match вызывает f(question, answer)
+func (f AnswerMatcherFunc) Match(question model.Question, answer string) (bool, error) {
+	return f(question, answer)
+}
+
+// answerMatchers зарегистрированные способы проверки, ключ - значение questions.answer_matcher
+var answerMatchers = map[string]AnswerMatcher{
+	model.MatcherExact:           AnswerMatcherFunc(matchExact),
+	model.MatcherCaseInsensitive: AnswerMatcherFunc(matchCaseInsensitive),
+	model.MatcherRegex:           AnswerMatcherFunc(matchRegex),
+	model.MatcherNumeric:         AnswerMatcherFunc(matchNumeric),
+	model.MatcherSynonyms:        AnswerMatcherFunc(matchSynonyms),
+}
+
+// RegisterAnswerMatcher добавляет или заменяет способ проверки свободного ответа.
+// Вызывается при инициализации приложения, до начала обработки ответов.
+func RegisterAnswerMatcher(name string, matcher AnswerMatcher) {
+	answerMatchers[name] = matcher
+}
+
+// CheckTextAnswer проверяет свободный ответ кандидата способом, указанным в вопросе
+func (s *TestService) CheckTextAnswer(question model.Question, answer string) (bool, error) {
+	matcherName := question.AnswerMatcher
+	if matcherName == "" {
+		matcherName = model.MatcherExact
+	}
+
+	matcher, ok := answerMatchers[matcherName]
+	if !ok {
+		return false, fmt.Errorf("unknown answer matcher %q for question %d", matcherName, question.ID)
+	}
+
+	isCorrect, err := matcher.Match(question, strings.TrimSpace(answer))
+	if err != nil {
+		return false, fmt.Errorf("failed to match answer for question %d: %w", question.ID, err)
+	}
+	return isCorrect, nil
+}
+
+// matchExact требует точного совпадения с правильным ответом
+func matchExact(question model.Question, answer string) (bool, error) {
+	return answer == strings.TrimSpace(question.CorrectAnswer), nil
+}
+
+// matchCaseInsensitive сравнивает ответы без учета регистра и лишних пробелов
+func matchCaseInsensitive(question model.Question, answer string) (bool, error) {
+	return normalizeAnswer(answer) == normalizeAnswer(question.CorrectAnswer), nil
+}
+
+// matchRegex проверяет ответ регулярным выражением из correct_answer.
+// Выражение должно совпасть с ответом целиком.
+func matchRegex(question model.Question, answer string) (bool, error) {
+	re, err := regexp.Compile(`^(?:` + question.CorrectAnswer + `)$`)
+	if err != nil {
+		return false, fmt.Errorf("invalid regex %q: %w", question.CorrectAnswer, err)
+	}
+	return re.MatchString(answer), nil
+}
+
+// matchNumeric сравнивает числа с допустимой погрешностью numeric_tolerance
+func matchNumeric(question model.Question, answer string) (bool, error) {
+	expected, err := parseNumber(question.CorrectAnswer)
+	if err != nil {
+		return false, fmt.Errorf("invalid numeric correct answer %q: %w", question.CorrectAnswer, err)
+	}
+
+	actual, err := parseNumber(answer)
+	if err != nil {
+		// Кандидат ввел не число - ответ неверный, но это не ошибка проверки
+		return false, nil
+	}
+
+	tolerance := 0.0
+	if question.NumericTolerance != nil {
+		tolerance = math.Abs(*question.NumericTolerance)
+	}
+	return math.Abs(actual-expected) <= tolerance, nil
+}
+
+// matchSynonyms принимает правильный ответ или любой из синонимов accepted_answers без учета регистра
+func matchSynonyms(question model.Question, answer string) (bool, error) {
+	normalized := normalizeAnswer(answer)
+	for _, accepted := range append([]string{question.CorrectAnswer}, question.AcceptedAnswers...) {
+		if normalized == normalizeAnswer(accepted) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// normalizeAnswer приводит ответ к нижнему регистру и схлопывает пробелы
+func normalizeAnswer(answer string) string {
+	return strings.ToLower(strings.Join(strings.Fields(answer), " "))
+}
+
+// parseNumber разбирает число, допуская запятую в качестве десятичного разделителя
+func parseNumber(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", ".")
+	return strconv.ParseFloat(value, 64)
+}
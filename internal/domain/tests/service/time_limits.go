@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"fmt"
+)
+
+// SaveQuestionMessageID сохраняет ID сообщения с текущим вопросом, чтобы обновлять в нем обратный отсчет
+func (s *TestService) SaveQuestionMessageID(ctx context.Context, userTestID int, messageID int) error {
+	err := s.testRepo.SaveQuestionMessageID(ctx, userTestID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to save question message ID: %w", err)
+	}
+	return nil
+}
+
+// SaveQuestionSelection сохраняет отмеченные варианты вопроса с несколькими ответами,
+// чтобы обновление обратного отсчета не сбрасывало выбор кандидата
+func (s *TestService) SaveQuestionSelection(ctx context.Context, userTestID int, selection uint64) error {
+	err := s.testRepo.SaveQuestionSelection(ctx, userTestID, selection)
+	if err != nil {
+		return fmt.Errorf("failed to save question selection: %w", err)
+	}
+	return nil
+}
+
+// ExpireQuestion засчитывает вопрос с истекшим временем без ответа и переводит кандидата к следующему вопросу.
+// Возвращает false, если кандидат успел ответить или тест уже завершен.
+func (s *TestService) ExpireQuestion(ctx context.Context, userTestID int, questionIndex int) (bool, error) {
+	expired, err := s.testRepo.ExpireQuestion(ctx, userTestID, questionIndex)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire question: %w", err)
+	}
+	return expired, nil
+}
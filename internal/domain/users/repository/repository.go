@@ -113,3 +113,68 @@ func (r *UserRepository) GetUserTestByID(ctx context.Context, userTestID int) (*
 	}
 	return &userTest, nil
 }
+
+// CreatePendingPromotion сохраняет отложенное назначение роли. Предыдущее отложенное назначение пользователя заменяется.
+func (r *UserRepository) CreatePendingPromotion(ctx context.Context, username string, roleID int, promotedBy int) (int, error) {
+	query := `
+        INSERT INTO pending_promotions (telegram_username, role_id, promoted_by)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (telegram_username) WHERE status = 'pending'
+        DO UPDATE SET role_id = EXCLUDED.role_id,
+                      promoted_by = EXCLUDED.promoted_by,
+                      updated_at = CURRENT_TIMESTAMP
+        RETURNING id
+    `
+	var promotionID int
+	err := r.db.QueryRow(ctx, query, username, roleID, promotedBy).Scan(&promotionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create pending promotion: %w", err)
+	}
+	return promotionID, nil
+}
+
+// ApplyPendingPromotion назначает пользователю роль из отложенного назначения и отмечает его примененным.
+// Если отложенного назначения нет, возвращает nil.
+func (r *UserRepository) ApplyPendingPromotion(ctx context.Context, userID int, username string) (*model.PendingPromotion, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	promotion := model.PendingPromotion{TelegramUsername: username}
+	err = tx.QueryRow(ctx, `
+        SELECT p.id, p.role_id, r.role_name, p.promoted_by
+        FROM pending_promotions p
+        JOIN roles r ON r.id = p.role_id
+        WHERE p.telegram_username = $1 AND p.status = 'pending'
+        FOR UPDATE OF p
+    `, username).Scan(&promotion.ID, &promotion.RoleID, &promotion.RoleName, &promotion.PromotedBy)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get pending promotion: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE users SET role_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2", promotion.RoleID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE pending_promotions
+        SET status = 'applied',
+            applied_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, promotion.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark pending promotion applied: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &promotion, nil
+}
@@ -64,6 +64,9 @@ func (s *UserService) GetPermissionsForUser(ctx context.Context, username string
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	if user == nil {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
 
 	permissions, err := s.rolePermissionRepo.GetPermissionsByRoleId(ctx, user.RoleID)
 	if err != nil {
@@ -111,6 +114,12 @@ func (s *UserService) GetRoleBasedKeyboard(ctx context.Context, username string,
 				Unique: "assign_admin",
 				Data:   "assign_admin",
 			})
+		case model.PermissionGenerateQR:
+			keyboard = append(keyboard, telebot.InlineButton{
+				Text:   buttonsMessages[model.GenerateQRKey],
+				Unique: model.GenerateQRKey,
+				Data:   model.GenerateQRKey,
+			})
 		}
 	}
 
@@ -165,3 +174,47 @@ func (s *UserService) GetUserTestByID(ctx context.Context, userTestID int) (*mod
 	}
 	return userTest, nil
 }
+
+// PromoteUser назначает пользователю роль от имени promotedByUsername. Если пользователь еще не писал /start,
+// создается отложенное назначение, которое применится при первом /start, и возвращается nil пользователь.
+func (s *UserService) PromoteUser(ctx context.Context, username string, roleName string, promotedByUsername string) (*model.User, error) {
+	role, err := s.rolePermissionRepo.GetRoleByRoleName(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role by name: %w", err)
+	}
+
+	promotedBy, err := s.userRepo.GetUserByUsername(ctx, promotedByUsername)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promoting user: %w", err)
+	}
+	if promotedBy == nil {
+		return nil, fmt.Errorf("promoting user %s not found", promotedByUsername)
+	}
+
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		if _, err := s.userRepo.CreatePendingPromotion(ctx, username, role.ID, promotedBy.ID); err != nil {
+			return nil, fmt.Errorf("failed to create pending promotion: %w", err)
+		}
+		return nil, nil
+	}
+
+	if _, err := s.UpdateUserRole(ctx, username, roleName); err != nil {
+		return nil, err
+	}
+	user.RoleID = role.ID
+	return user, nil
+}
+
+// ApplyPendingPromotion применяет отложенное назначение роли пользователя, если оно есть
+func (s *UserService) ApplyPendingPromotion(ctx context.Context, userID int, username string) (*model.PendingPromotion, error) {
+	promotion, err := s.userRepo.ApplyPendingPromotion(ctx, userID, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply pending promotion: %w", err)
+	}
+	return promotion, nil
+}
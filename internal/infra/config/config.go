@@ -3,17 +3,52 @@ package config
 import (
 	"fmt"
 	"gopkg.in/yaml.v3"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // База часовых поясов встраивается в бинарник: в контейнере ее может не быть
 )
 
+// Режимы получения обновлений Telegram бота
+const (
+	BotModePolling = "polling"
+	BotModeWebhook = "webhook"
+)
+
+// DefaultWebhookPath путь вебхука по умолчанию
+const DefaultWebhookPath = "/telegram"
+
+// Параметры сессий HR панели по умолчанию
+const (
+	DefaultSessionTTL  = 12 * time.Hour
+	DefaultLoginMaxAge = 24 * time.Hour
+)
+
+// Параметры QR-кодов ссылок на тесты по умолчанию
+const (
+	DefaultQRSize  = 256
+	DefaultQRLevel = "M"
+)
+
+// DefaultShutdownTimeout дедлайн корректной остановки приложения по умолчанию
+const DefaultShutdownTimeout = 30 * time.Second
+
 type Config struct {
 	Server struct {
-		Host string `yaml:"host"`
-		Port string `yaml:"port"`
+		Host            string        `yaml:"host"`
+		Port            string        `yaml:"port"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // Дедлайн корректной остановки приложения, например "30s"
+		PublicURL       string        `yaml:"public_url"`       // Внешний адрес HTTP сервера со схемой, например "https://hr.example.com"
 	} `yaml:"server"`
 	TelegramBot struct {
-		Token       string `yaml:"token"`
-		BotUsername string `yaml:"username"`
+		Token          string `yaml:"token"`
+		BotUsername    string `yaml:"username"`
+		Mode           string `yaml:"mode"`            // Режим получения обновлений: "polling" (по умолчанию) или "webhook"
+		WebhookURL     string `yaml:"webhook_url"`     // Публичный URL вебхука, на который Telegram отправляет обновления
+		WebhookPath    string `yaml:"webhook_path"`    // Путь вебхука на HTTP сервере приложения, по умолчанию "/telegram"
+		WebhookSecret  string `yaml:"webhook_secret"`  // Секретный токен, который Telegram передает в заголовке X-Telegram-Bot-Api-Secret-Token
+		CallbackSecret string `yaml:"callback_secret"` // Ключ подписи кнопок ответа на вопросы; если не задан, выводится из токена бота
 	} `yaml:"telegram_bot"`
 	Database struct {
 		Host     string `yaml:"host"`
@@ -22,6 +57,57 @@ type Config struct {
 		Password string `yaml:"password"`
 		Name     string `yaml:"dbname"`
 	} `yaml:"database"`
+	Auth struct {
+		SessionSecret string        `yaml:"session_secret"` // Ключ подписи сессий HR панели; если не задан, выводится из токена бота
+		SessionTTL    time.Duration `yaml:"session_ttl"`    // Время жизни сессии, например "12h"
+		LoginMaxAge   time.Duration `yaml:"login_max_age"`  // Максимальный возраст данных Telegram Login Widget, например "24h"
+	} `yaml:"auth"`
+	Timer struct {
+		EditsPerSecond float64 `yaml:"edits_per_second"` // Общий лимит обновлений сообщений с таймером в секунду
+		Burst          int     `yaml:"burst"`            // Допустимый кратковременный всплеск обновлений
+	} `yaml:"timer"`
+	Deadlines struct {
+		CheckInterval time.Duration `yaml:"check_interval"` // Период проверки просроченных назначений, например "1m"
+	} `yaml:"deadlines"`
+	Reminders struct {
+		CheckInterval   time.Duration   `yaml:"check_interval"`   // Период проверки напоминаний, например "5m"
+		BeforeDeadline  []time.Duration `yaml:"before_deadline"`  // За сколько до срока назначения напоминать, например ["24h", "2h"]
+		AfterAssignment []time.Duration `yaml:"after_assignment"` // Через сколько после назначения напоминать, например ["24h"]
+	} `yaml:"reminders"`
+	QR struct {
+		Size     int    `yaml:"size"`      // Размер QR-кода в пикселях по умолчанию
+		Level    string `yaml:"level"`     // Уровень коррекции ошибок по умолчанию: L, M, Q или H
+		LogoPath string `yaml:"logo_path"` // Путь к логотипу компании (PNG или JPEG), накладываемому на QR-код
+	} `yaml:"qr"`
+
+	Timezone string `yaml:"timezone"` // Часовой пояс дат в сообщениях (IANA, например "Europe/Moscow"), по умолчанию пояс сервера
+}
+
+// Location возвращает часовой пояс, в котором даты выводятся пользователям
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	return location, nil
+}
+
+// PublicBaseURL возвращает внешний адрес HTTP сервера без завершающего слеша, из него строятся ссылки на QR-коды
+func (c *Config) PublicBaseURL() (string, error) {
+	if c.Server.PublicURL == "" {
+		return "", fmt.Errorf("server.public_url is required")
+	}
+	publicURL, err := url.Parse(c.Server.PublicURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid server.public_url %q: %w", c.Server.PublicURL, err)
+	}
+	if publicURL.Scheme != "https" && publicURL.Scheme != "http" || publicURL.Host == "" {
+		return "", fmt.Errorf("server.public_url %q must be an absolute http(s) URL", c.Server.PublicURL)
+	}
+	return strings.TrimSuffix(publicURL.String(), "/"), nil
 }
 
 func LoadConfig(filename string) (*Config, error) {
@@ -0,0 +1,106 @@
+package deadline
+
+import (
+	"context"
+	"fmt"
+	messageService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"gopkg.in/telebot.v4"
+	"html"
+	"log"
+	"time"
+)
+
+// defaultCheckInterval период проверки просроченных назначений по умолчанию
+const defaultCheckInterval = time.Minute
+
+// layout формат срока в уведомлениях
+const layout = "02.01.2006 15:04"
+
+// Watcher фоновая задача, которая переводит назначения с истекшим сроком в статус expired
+// и уведомляет об этом кандидата и назначившего тест HR
+type Watcher struct {
+	bot            *telebot.Bot
+	testService    *testsService.TestService
+	messageService *messageService.MessageService
+	interval       time.Duration
+	location       *time.Location // Часовой пояс срока в уведомлениях
+}
+
+// NewWatcher создает фоновую задачу. При нулевом interval используется период по умолчанию,
+// срок в уведомлениях выводится в часовом поясе location
+func NewWatcher(bot *telebot.Bot, testService *testsService.TestService, messageService *messageService.MessageService, interval time.Duration, location *time.Location) *Watcher {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	return &Watcher{
+		bot:            bot,
+		testService:    testService,
+		messageService: messageService,
+		interval:       interval,
+		location:       location,
+	}
+}
+
+// Run проверяет просроченные назначения сразу и затем каждые interval, пока не отменен ctx
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expire переводит просроченные назначения в статус expired и рассылает уведомления
+func (w *Watcher) expire(ctx context.Context) {
+	expired, err := w.testService.ExpireOverdueAssignments(ctx)
+	if err != nil {
+		log.Printf("Failed to expire overdue assignments: %v", err)
+		return
+	}
+
+	for _, assignment := range expired {
+		if ctx.Err() != nil {
+			return
+		}
+		w.notify(ctx, assignment)
+	}
+}
+
+// notify уведомляет кандидата (если он уже писал боту) и HR о просроченном назначении
+func (w *Watcher) notify(ctx context.Context, assignment model.ExpiredAssignment) {
+	// Сообщения отправляются в режиме HTML, поэтому название теста и username экранируются
+	testName := html.EscapeString(assignment.TestName)
+	deadline := assignment.Deadline.In(w.location).Format(layout)
+
+	if assignment.CandidateTelegramID != nil {
+		w.send(ctx, *assignment.CandidateTelegramID, "assignment_expired_candidate", testName, deadline)
+	}
+	if assignment.HRTelegramID != nil {
+		w.send(ctx, *assignment.HRTelegramID, "assignment_expired_hr", html.EscapeString(assignment.CandidateUsername), testName, deadline)
+	}
+}
+
+// send отправляет сообщение messageKey с параметрами args пользователю telegramID
+func (w *Watcher) send(ctx context.Context, telegramID int64, messageKey string, args ...any) {
+	message, err := w.messageService.GetMessageByKey(ctx, messageKey)
+	if err != nil {
+		log.Printf("Failed to get %s message: %v", messageKey, err)
+		return
+	}
+
+	_, err = w.bot.Send(&telebot.User{ID: telegramID}, fmt.Sprintf(message, args...), &telebot.SendOptions{
+		ParseMode: telebot.ModeHTML,
+	})
+	if err != nil {
+		log.Printf("Failed to send %s to %d: %v", messageKey, telegramID, err)
+	}
+}
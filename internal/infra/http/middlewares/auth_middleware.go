@@ -0,0 +1,88 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	authService "github.com/IT-Nick/internal/domain/auth/service"
+	"github.com/IT-Nick/internal/domain/model"
+	rolesService "github.com/IT-Nick/internal/domain/roles/service"
+	httpError "github.com/IT-Nick/pkg/http"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// SessionCookieName cookie с JWT сессии HR панели
+const SessionCookieName = "hr_session"
+
+// userContextKey ключ контекста запроса, под которым хранится аутентифицированный пользователь
+type userContextKey struct{}
+
+// UserFromContext возвращает пользователя, аутентифицированного AuthMiddleware
+func UserFromContext(ctx context.Context) *model.User {
+	user, _ := ctx.Value(userContextKey{}).(*model.User)
+	return user
+}
+
+// AuthMiddleware проверяет API ключ или JWT сессии из заголовка Authorization: Bearer (либо сессию из cookie)
+// и право пользователя на маршрут. Аутентифицированный пользователь передается обработчику через контекст запроса.
+type AuthMiddleware struct {
+	h           http.Handler
+	authService *authService.AuthService
+	roleService *rolesService.RoleService
+	permission  string
+}
+
+func NewAuthMiddleware(h http.Handler, authService *authService.AuthService, roleService *rolesService.RoleService, permission string) http.Handler {
+	return &AuthMiddleware{
+		h:           h,
+		authService: authService,
+		roleService: roleService,
+		permission:  permission,
+	}
+}
+
+func (m *AuthMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := bearerToken(r)
+	if token == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		httpError.ErrorResponse(w, http.StatusUnauthorized, "Missing bearer token")
+		return
+	}
+
+	user, err := m.authService.Authenticate(ctx, token)
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to authenticate: %v", err))
+		return
+	}
+	if user == nil {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		httpError.ErrorResponse(w, http.StatusUnauthorized, "Invalid api key or session")
+		return
+	}
+
+	permissions, err := m.roleService.GetPermissionsForUser(ctx, user.RoleID)
+	if err != nil {
+		httpError.ErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve permissions")
+		return
+	}
+	if !slices.Contains(permissions, m.permission) {
+		httpError.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("Permission %s required", m.permission))
+		return
+	}
+
+	m.h.ServeHTTP(w, r.WithContext(context.WithValue(ctx, userContextKey{}, user)))
+}
+
+// bearerToken возвращает токен из заголовка Authorization или, если его нет, из cookie сессии
+func bearerToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	if cookie, err := r.Cookie(SessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
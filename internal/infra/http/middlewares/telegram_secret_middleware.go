@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	httpError "github.com/IT-Nick/pkg/http"
+	"net/http"
+)
+
+// telegramSecretHeader заголовок, в котором Telegram передает secret_token, указанный при установке вебхука
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// TelegramSecretMiddleware пропускает к вебхуку только запросы с верным секретным токеном
+type TelegramSecretMiddleware struct {
+	h      http.Handler
+	secret []byte
+}
+
+func NewTelegramSecretMiddleware(h http.Handler, secret string) http.Handler {
+	return &TelegramSecretMiddleware{h: h, secret: []byte(secret)}
+}
+
+func (m *TelegramSecretMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := []byte(r.Header.Get(telegramSecretHeader))
+	if subtle.ConstantTimeCompare(token, m.secret) != 1 {
+		httpError.ErrorResponse(w, http.StatusUnauthorized, "invalid secret token")
+		return
+	}
+
+	m.h.ServeHTTP(w, r)
+}
@@ -0,0 +1,235 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/skip2/go-qrcode"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// Допустимый размер QR-кода в пикселях
+const (
+	MinSize = 64
+	MaxSize = 2048
+)
+
+// logoScale доля стороны QR-кода, которую занимает логотип
+const logoScale = 5
+
+var (
+	// ErrInvalidSize размер QR-кода вне диапазона MinSize..MaxSize
+	ErrInvalidSize = fmt.Errorf("qr size must be between %d and %d", MinSize, MaxSize)
+	// ErrInvalidLevel неизвестный уровень коррекции ошибок
+	ErrInvalidLevel = errors.New("qr level must be one of L, M, Q, H")
+)
+
+// levels уровни коррекции ошибок в обозначениях стандарта QR
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options параметры отрисовки QR-кода. Нулевые значения заменяются настройками Renderer
+type Options struct {
+	Size   int    // Сторона изображения в пикселях
+	Level  string // Уровень коррекции ошибок: L, M, Q или H
+	NoLogo bool   // Не накладывать логотип компании
+}
+
+// Renderer рисует QR-коды в PNG и SVG с настройками по умолчанию и необязательным логотипом компании
+type Renderer struct {
+	size  int
+	level qrcode.RecoveryLevel
+	logo  image.Image
+}
+
+// NewRenderer создает Renderer. logoPath - путь к PNG или JPEG логотипу, пустая строка - без логотипа
+func NewRenderer(size int, level string, logoPath string) (*Renderer, error) {
+	if size < MinSize || size > MaxSize {
+		return nil, ErrInvalidSize
+	}
+	recoveryLevel, err := parseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer := &Renderer{size: size, level: recoveryLevel}
+	if logoPath != "" {
+		renderer.logo, err = loadLogo(logoPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return renderer, nil
+}
+
+// PNG рисует QR-код с содержимым content в формате PNG
+func (r *Renderer) PNG(content string, opts Options) ([]byte, error) {
+	code, size, logo, err := r.prepare(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	img := code.Image(size)
+	if logo != nil {
+		canvas := image.NewRGBA(img.Bounds())
+		draw.Draw(canvas, canvas.Bounds(), img, image.Point{}, draw.Src)
+		overlayLogo(canvas, logo)
+		img = canvas
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG рисует QR-код с содержимым content в формате SVG
+func (r *Renderer) SVG(content string, opts Options) ([]byte, error) {
+	code, size, logo, err := r.prepare(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, modules, modules)
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000000"/>`, path.String())
+
+	if logo != nil {
+		var logoPNG bytes.Buffer
+		if err := png.Encode(&logoPNG, logo); err != nil {
+			return nil, fmt.Errorf("failed to encode logo: %w", err)
+		}
+		logoSize := float64(modules) / logoScale
+		offset := (float64(modules) - logoSize) / 2
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#ffffff"/>`,
+			offset-0.5, offset-0.5, logoSize+1, logoSize+1)
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="xMidYMid meet" href="data:image/png;base64,%s"/>`,
+			offset, offset, logoSize, logoSize, base64.StdEncoding.EncodeToString(logoPNG.Bytes()))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// prepare применяет настройки по умолчанию и кодирует содержимое в QR-код
+func (r *Renderer) prepare(content string, opts Options) (*qrcode.QRCode, int, image.Image, error) {
+	size := r.size
+	if opts.Size != 0 {
+		if opts.Size < MinSize || opts.Size > MaxSize {
+			return nil, 0, nil, ErrInvalidSize
+		}
+		size = opts.Size
+	}
+
+	level := r.level
+	if opts.Level != "" {
+		parsed, err := parseLevel(opts.Level)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		level = parsed
+	}
+
+	logo := r.logo
+	if opts.NoLogo {
+		logo = nil
+	}
+	// Логотип закрывает центр кода, поэтому уровень коррекции поднимается минимум до Q
+	if logo != nil && level < qrcode.High {
+		level = qrcode.High
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+	return code, size, logo, nil
+}
+
+// parseLevel разбирает уровень коррекции ошибок, пустая строка - уровень M
+func parseLevel(level string) (qrcode.RecoveryLevel, error) {
+	if level == "" {
+		return qrcode.Medium, nil
+	}
+	recoveryLevel, ok := levels[strings.ToUpper(level)]
+	if !ok {
+		return 0, ErrInvalidLevel
+	}
+	return recoveryLevel, nil
+}
+
+// loadLogo читает логотип компании из файла
+func loadLogo(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logo: %w", err)
+	}
+	defer f.Close()
+
+	logo, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+	return logo, nil
+}
+
+// overlayLogo рисует логотип в центре QR-кода на белой подложке, сохраняя пропорции логотипа
+func overlayLogo(canvas *image.RGBA, logo image.Image) {
+	bounds := canvas.Bounds()
+	box := bounds.Dx() / logoScale
+	if box <= 0 {
+		return
+	}
+
+	logoBounds := logo.Bounds()
+	width, height := box, box
+	if logoBounds.Dx() > logoBounds.Dy() {
+		height = box * logoBounds.Dy() / logoBounds.Dx()
+	} else if logoBounds.Dy() > logoBounds.Dx() {
+		width = box * logoBounds.Dx() / logoBounds.Dy()
+	}
+
+	// Белая подложка с небольшим отступом вокруг логотипа
+	padding := box / 10
+	center := bounds.Min.Add(image.Pt(bounds.Dx()/2, bounds.Dy()/2))
+	plate := image.Rect(center.X-box/2-padding, center.Y-box/2-padding, center.X+box/2+padding, center.Y+box/2+padding)
+	draw.Draw(canvas, plate, image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	// Масштабирование методом ближайшего соседа
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx := logoBounds.Min.X + x*logoBounds.Dx()/width
+			sy := logoBounds.Min.Y + y*logoBounds.Dy()/height
+			scaled.Set(x, y, logo.At(sx, sy))
+		}
+	}
+	origin := image.Pt(center.X-width/2, center.Y-height/2)
+	draw.Draw(canvas, scaled.Bounds().Add(origin), scaled, image.Point{}, draw.Over)
+}
@@ -0,0 +1,166 @@
+package reminder
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	messageService "github.com/IT-Nick/internal/domain/messages/service"
+	"github.com/IT-Nick/internal/domain/model"
+	testsService "github.com/IT-Nick/internal/domain/tests/service"
+	"gopkg.in/telebot.v4"
+	"html"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// defaultCheckInterval период проверки напоминаний по умолчанию
+const defaultCheckInterval = 5 * time.Minute
+
+// layout формат срока в напоминаниях
+const layout = "02.01.2006 15:04"
+
+// Scheduler фоновая задача, которая напоминает кандидатам о назначенных, но не начатых тестах:
+// за заданное время до срока назначения и через заданное время после назначения.
+// Отправленные напоминания записываются в sent_reminders до отправки, поэтому перезапуск не приводит к дублям.
+type Scheduler struct {
+	bot             *telebot.Bot
+	testService     *testsService.TestService
+	messageService  *messageService.MessageService
+	interval        time.Duration
+	beforeDeadline  []time.Duration // По убыванию: от самого раннего напоминания к самому позднему
+	afterAssignment []time.Duration
+	location        *time.Location // Часовой пояс срока в напоминаниях
+}
+
+// NewScheduler создает планировщик напоминаний. При нулевом interval используется период по умолчанию,
+// неположительные значения в списках напоминаний пропускаются. Срок выводится в часовом поясе location
+func NewScheduler(
+	bot *telebot.Bot,
+	testService *testsService.TestService,
+	messageService *messageService.MessageService,
+	interval time.Duration,
+	beforeDeadline []time.Duration,
+	afterAssignment []time.Duration,
+	location *time.Location,
+) *Scheduler {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	beforeDeadline = positive(beforeDeadline)
+	slices.SortFunc(beforeDeadline, func(a, b time.Duration) int { return cmp.Compare(b, a) })
+
+	return &Scheduler{
+		bot:             bot,
+		testService:     testService,
+		messageService:  messageService,
+		interval:        interval,
+		beforeDeadline:  beforeDeadline,
+		afterAssignment: positive(afterAssignment),
+		location:        location,
+	}
+}
+
+// Run проверяет напоминания сразу и затем каждые interval, пока не отменен ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.beforeDeadline) == 0 && len(s.afterAssignment) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.remind(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remind отправляет все напоминания, время которых наступило
+func (s *Scheduler) remind(ctx context.Context) {
+	for i, before := range s.beforeDeadline {
+		// Если подошло время следующего напоминания, более раннее уже не отправляется
+		var notAfter time.Duration
+		if i+1 < len(s.beforeDeadline) {
+			notAfter = s.beforeDeadline[i+1]
+		}
+		key := fmt.Sprintf("before_deadline_%s", before)
+		reminders, err := s.testService.ClaimDeadlineReminders(ctx, key, before, notAfter)
+		if err != nil {
+			log.Printf("Failed to claim reminders %s: %v", key, err)
+			continue
+		}
+		s.send(ctx, reminders, "reminder_before_deadline", true)
+	}
+
+	for _, after := range s.afterAssignment {
+		key := fmt.Sprintf("after_assignment_%s", after)
+		reminders, err := s.testService.ClaimAssignmentReminders(ctx, key, after)
+		if err != nil {
+			log.Printf("Failed to claim reminders %s: %v", key, err)
+			continue
+		}
+		s.send(ctx, reminders, "reminder_after_assignment", false)
+	}
+}
+
+// send отправляет напоминания с текстом messageKey и кнопкой начала теста.
+// withDeadline - текст содержит срок назначения вторым параметром
+func (s *Scheduler) send(ctx context.Context, reminders []model.Reminder, messageKey string, withDeadline bool) {
+	if len(reminders) == 0 {
+		return
+	}
+
+	message, err := s.messageService.GetMessageByKey(ctx, messageKey)
+	if err != nil {
+		log.Printf("Failed to get %s message: %v", messageKey, err)
+		return
+	}
+	buttons, err := s.messageService.GetButtons(ctx)
+	if err != nil {
+		log.Printf("Failed to get buttons: %v", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		if ctx.Err() != nil {
+			return
+		}
+
+		var text string
+		if withDeadline && reminder.Deadline != nil {
+			text = fmt.Sprintf(message, html.EscapeString(reminder.TestName), reminder.Deadline.In(s.location).Format(layout))
+		} else {
+			text = fmt.Sprintf(message, html.EscapeString(reminder.TestName))
+		}
+
+		// Кнопка начинает именно это назначение, даже если кандидату назначено несколько тестов
+		markup := &telebot.ReplyMarkup{}
+		markup.Inline(markup.Row(markup.Data(buttons[model.StartTestKey], model.StartTestKey, strconv.Itoa(reminder.UserTestID))))
+
+		_, err := s.bot.Send(&telebot.User{ID: reminder.TelegramID}, text, &telebot.SendOptions{
+			ParseMode:   telebot.ModeHTML,
+			ReplyMarkup: markup,
+		})
+		if err != nil {
+			log.Printf("Failed to send reminder for user test %d: %v", reminder.UserTestID, err)
+		}
+	}
+}
+
+// positive возвращает копию списка без неположительных значений
+func positive(durations []time.Duration) []time.Duration {
+	result := make([]time.Duration, 0, len(durations))
+	for _, d := range durations {
+		if d > 0 {
+			result = append(result, d)
+		}
+	}
+	return result
+}
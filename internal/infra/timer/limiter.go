@@ -0,0 +1,79 @@
+package timer
+
+import (
+	"context"
+	"time"
+)
+
+// tokenBucket ограничивает общее количество запросов к Telegram API от планировщика таймеров.
+// Используется только из цикла планировщика, поэтому не требует синхронизации.
+type tokenBucket struct {
+	rate        float64   // Скорость пополнения, токенов в секунду
+	burst       float64   // Максимальное количество накопленных токенов
+	tokens      float64   // Доступные токены
+	last        time.Time // Время последнего пополнения
+	pausedUntil time.Time // До этого времени запросы запрещены (ответ 429 от Telegram)
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill начисляет токены за время, прошедшее с последнего пополнения
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// Allow забирает токен, если он доступен, не блокируя вызывающего
+func (b *tokenBucket) Allow(now time.Time) bool {
+	if now.Before(b.pausedUntil) {
+		return false
+	}
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait ожидает доступный токен и забирает его
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		now := time.Now()
+		if b.Allow(now) {
+			return nil
+		}
+
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		if now.Before(b.pausedUntil) {
+			delay = b.pausedUntil.Sub(now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Pause запрещает запросы до указанного времени
+func (b *tokenBucket) Pause(until time.Time) {
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// PausedUntil возвращает время окончания паузы
+func (b *tokenBucket) PausedUntil() time.Time {
+	return b.pausedUntil
+}
@@ -0,0 +1,44 @@
+package timer
+
+import (
+	"github.com/IT-Nick/internal/domain/model"
+	"time"
+)
+
+// entry таймер теста в очереди планировщика
+type entry struct {
+	model.ActiveTimer
+	nextUpdate  time.Time // Время следующего обновления сообщения или завершения теста
+	lastText    string    // Последний отправленный текст таймера
+	wakePending bool      // Wake вызван во время обработки, таймер нужно обновить сразу после нее
+	index       int       // Позиция в куче, поддерживается методами heap.Interface
+}
+
+// queue мин-куча таймеров, упорядоченная по времени следующего обновления
+type queue []*entry
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool { return q[i].nextUpdate.Before(q[j].nextUpdate) }
+
+func (q queue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *queue) Push(x any) {
+	e := x.(*entry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *queue) Pop() any {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*q = old[:n-1]
+	return e
+}
@@ -1,133 +1,463 @@
 package timer
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/IT-Nick/internal/domain/model"
 	testsService "github.com/IT-Nick/internal/domain/tests/service"
 	usersService "github.com/IT-Nick/internal/domain/users/service"
 	"gopkg.in/telebot.v4"
 	"log"
+	"sync"
 	"time"
 )
 
+const (
+	retryInterval  = time.Second     // Повтор обновления, если лимит запросов исчерпан или база данных недоступна
+	idleInterval   = 5 * time.Minute // Период ожидания, когда активных таймеров нет
+	defaultEditsPS = 20              // Лимит обновлений в секунду по умолчанию (глобальный лимит Telegram - около 30 сообщений в секунду)
+
+	countdownInterval = 10 * time.Second // Период обновления обратного отсчета в сообщении с вопросом
+)
+
+// refreshInterval возвращает период обновления сообщения в зависимости от оставшегося времени:
+// в начале теста таймер обновляется редко, в последнюю минуту - каждые 5 секунд
+func refreshInterval(timeLeft time.Duration) time.Duration {
+	switch {
+	case timeLeft <= time.Minute:
+		return 5 * time.Second
+	case timeLeft <= 5*time.Minute:
+		return 15 * time.Second
+	default:
+		return 30 * time.Second
+	}
+}
+
+// Updater планировщик таймеров тестов. Все таймеры обслуживаются одним циклом по мин-куче,
+// упорядоченной по времени следующего обновления, а прогресс кандидатов читается одним запросом на пачку таймеров.
+// При запуске таймеры восстанавливаются из user_tests, поэтому перезапуск бота не теряет тесты в процессе прохождения.
 type Updater struct {
 	bot         *telebot.Bot
 	testService *testsService.TestService
 	userService *usersService.UserService
+
+	mutex   sync.Mutex
+	queue   queue
+	entries map[int]*entry // Таймеры по user_test_id
+	wake    chan struct{}
+
+	limiter *tokenBucket // Общий бюджет запросов к Telegram, используется только циклом планировщика
+
+	presenter QuestionPresenter // Показывает вопросы с ограничением времени, nil - ограничения вопросов не обслуживаются
 }
 
-func NewTimerUpdater(bot *telebot.Bot, testService *testsService.TestService, userService *usersService.UserService) *Updater {
+// QuestionPresenter показывает вопросы с ограничением времени на ответ.
+// Реализуется обработчиком ответов, который формирует сообщения с вопросами.
+type QuestionPresenter interface {
+	// ShowCountdown обновляет обратный отсчет в сообщении с текущим вопросом
+	ShowCountdown(ctx context.Context, telegramID int64, userTestID int, progress model.UserTestProgress, timeLeft time.Duration) error
+	// ExpireQuestion засчитывает текущий вопрос без ответа и показывает следующий.
+	// Возвращает true, если вопрос был последним и тест нужно завершить.
+	ExpireQuestion(ctx context.Context, telegramID int64, userTestID int, progress model.UserTestProgress) (bool, error)
+}
+
+// NewTimerUpdater создает планировщик таймеров. editsPerSecond и burst задают общий лимит обновлений сообщений,
+// при нулевых значениях используется лимит по умолчанию.
+func NewTimerUpdater(bot *telebot.Bot, testService *testsService.TestService, userService *usersService.UserService, editsPerSecond float64, burst int) *Updater {
+	if editsPerSecond <= 0 {
+		editsPerSecond = defaultEditsPS
+	}
+	if burst <= 0 {
+		burst = int(editsPerSecond)
+	}
 	return &Updater{
 		bot:         bot,
 		testService: testService,
 		userService: userService,
+		entries:     make(map[int]*entry),
+		wake:        make(chan struct{}, 1),
+		limiter:     newTokenBucket(editsPerSecond, max(burst, 1)),
+	}
+}
+
+// SetQuestionPresenter подключает показ вопросов с ограничением времени. Вызывается до Run.
+func (tu *Updater) SetQuestionPresenter(presenter QuestionPresenter) {
+	tu.presenter = presenter
+}
+
+// FormatTimer формирует текст сообщения с таймером и номером вопроса
+func FormatTimer(timeLeft time.Duration, currentQuestionIndex int, totalQuestions int) string {
+	if timeLeft < 0 {
+		timeLeft = 0
+	}
+	minutes := int(timeLeft.Minutes())
+	seconds := int(timeLeft.Seconds()) % 60
+	return fmt.Sprintf(
+		"⏰ Тест начался! Оставшееся время: %02d:%02d, Вопрос %d/%d",
+		minutes, seconds, currentQuestionIndex+1, totalQuestions,
+	)
+}
+
+// Schedule ставит таймер теста в очередь. Повторный вызов для того же user_test_id заменяет таймер.
+func (tu *Updater) Schedule(timer model.ActiveTimer) {
+	tu.mutex.Lock()
+	if e, ok := tu.entries[timer.UserTestID]; ok && e.index >= 0 {
+		if e.MessageID != timer.MessageID {
+			e.lastText = ""
+		}
+		e.ActiveTimer = timer
+		e.nextUpdate = time.Now()
+		heap.Fix(&tu.queue, e.index)
+	} else {
+		e = &entry{ActiveTimer: timer, nextUpdate: time.Now()}
+		tu.entries[timer.UserTestID] = e
+		heap.Push(&tu.queue, e)
+	}
+	tu.mutex.Unlock()
+
+	select {
+	case tu.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Wake немедленно обновляет таймер теста, например после показа вопроса с ограничением времени.
+// Если таймер сейчас обрабатывается, обновление выполняется сразу после обработки.
+func (tu *Updater) Wake(userTestID int) {
+	tu.mutex.Lock()
+	e, ok := tu.entries[userTestID]
+	if ok {
+		if e.index >= 0 {
+			e.nextUpdate = time.Now()
+			heap.Fix(&tu.queue, e.index)
+		} else {
+			e.wakePending = true
+		}
+	}
+	tu.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+	select {
+	case tu.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Restore загружает таймеры всех тестов в процессе прохождения из базы данных
+func (tu *Updater) Restore(ctx context.Context) error {
+	timers, err := tu.testService.GetActiveTimers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load active timers: %w", err)
+	}
+	for _, t := range timers {
+		tu.Schedule(t)
 	}
+	log.Printf("Restored %d active timers", len(timers))
+	return nil
 }
 
-// UpdateTimer обновляет сообщение с таймером, номером вопроса и статусом теста
-func (tu *Updater) UpdateTimer(ctx context.Context, userID int64, messageID int, deadline time.Time, userTestID int, totalQuestions int) {
-	ticker := time.NewTicker(time.Second)
-	defer ticker.Stop()
+// Run восстанавливает таймеры и обслуживает очередь до отмены контекста.
+// Состояние таймеров хранится в user_tests, поэтому после остановки ничего сохранять не требуется.
+func (tu *Updater) Run(ctx context.Context) {
+	if err := tu.Restore(ctx); err != nil {
+		log.Printf("Failed to restore timers: %v", err)
+	}
+
+	wait := time.NewTimer(idleInterval)
+	defer wait.Stop()
 
 	for {
+		wait.Reset(tu.untilNext())
+
 		select {
 		case <-ctx.Done():
-			// Контекст отменен, завершаем обновление таймера
-			log.Printf("Timer update canceled for user %d", userID)
+			log.Printf("Timer scheduler stopped")
 			return
-		case <-ticker.C:
-			// Вычисляем оставшееся время
-			timeLeft := time.Until(deadline)
-			log.Printf("Deadline: %s, Time left: %s", deadline, timeLeft)
-			if timeLeft <= 0 {
-				// Время вышло, проверяем статус теста
-				_, _, status, err := tu.testService.GetUserTestState(ctx, userTestID)
-				if err != nil {
-					log.Printf("Failed to get user test state for user %d: %v", userID, err)
-					return
-				}
-
-				if status != "finished" {
-					// Обновляем статус теста на "finished" и сохраняем end_time
-					endTime := time.Now()
-					err = tu.testService.UpdateUserTestStatus(ctx, userTestID, "finished")
-					err = tu.testService.UpdateUserTestEndTime(ctx, userTestID, endTime)
-					if err != nil {
-						log.Printf("Failed to update test status for user %d: %v", userID, err)
-					}
-
-					// Получаем пользователя, который назначил тест
-					userTest, err := tu.userService.GetUserTestByID(ctx, userTestID)
-					if err != nil {
-						log.Printf("Failed to get user test for user (ошибка в таймере) %d: %v", userID, err)
-					}
-
-					assignedByTgId, err := tu.userService.GetUserByID(ctx, userTest.AssignedBy)
-					if err != nil {
-						log.Printf("Ошибка получения HR менеджера в таймере: %v", err)
-					}
-
-					user, err := tu.userService.GetUserByTelegramID(ctx, userID)
-					if err != nil {
-						log.Printf("Ошибка получения пользователя по айди телеграм в таймере: %v", err)
-					}
-
-					test, err := tu.testService.GetLastTestForUserWithFinishStatus(ctx, user.TelegramUsername)
-					if err != nil {
-						log.Printf("GetLastTestForUserWithFinishStatus в таймере: %v", err)
-					}
-					// Отправляем сообщение о завершении теста пользователю assigned_by
-					_, err = tu.bot.Send(&telebot.User{ID: *assignedByTgId.TelegramID}, fmt.Sprintf("⚡️ Кандидат *%s* завершил выполнение теста *%s*.", user.TelegramUsername, test.TestName), &telebot.SendOptions{
-						ParseMode: telebot.ModeMarkdown,
-					})
-
-					// Отправляем сообщение о завершении времени
-					_, err = tu.bot.Edit(&telebot.Message{
-						ID:   messageID,
-						Chat: &telebot.Chat{ID: userID},
-					}, "⏰ Время вышло!", &telebot.SendOptions{
-						ParseMode: telebot.ModeMarkdown,
-					})
-					if err != nil {
-						log.Printf("Failed to update timer message for user %d: %w", userID, err)
-					}
-				}
-				return
-			}
+		case <-tu.wake:
+		case <-wait.C:
+		}
+
+		tu.process(ctx, time.Now())
+	}
+}
+
+// untilNext возвращает время до ближайшего обновления в очереди
+func (tu *Updater) untilNext() time.Duration {
+	tu.mutex.Lock()
+	defer tu.mutex.Unlock()
+
+	if tu.queue.Len() == 0 {
+		return idleInterval
+	}
+	return max(time.Until(tu.queue[0].nextUpdate), 0)
+}
+
+// popDue извлекает из очереди таймеры, время обновления которых наступило
+func (tu *Updater) popDue(now time.Time) []*entry {
+	tu.mutex.Lock()
+	defer tu.mutex.Unlock()
+
+	var due []*entry
+	for tu.queue.Len() > 0 && !tu.queue[0].nextUpdate.After(now) {
+		due = append(due, heap.Pop(&tu.queue).(*entry))
+	}
+	return due
+}
+
+// reschedule возвращает таймер в очередь, если за время обработки его не заменили через Schedule
+func (tu *Updater) reschedule(e *entry, nextUpdate time.Time) {
+	tu.mutex.Lock()
+	defer tu.mutex.Unlock()
+
+	if tu.entries[e.UserTestID] != e {
+		return
+	}
+	if e.wakePending {
+		e.wakePending = false
+		nextUpdate = time.Now()
+	}
+	e.nextUpdate = nextUpdate
+	heap.Push(&tu.queue, e)
+}
+
+// forget удаляет таймер, если за время обработки его не заменили через Schedule
+func (tu *Updater) forget(e *entry) {
+	tu.mutex.Lock()
+	defer tu.mutex.Unlock()
+
+	if tu.entries[e.UserTestID] == e {
+		delete(tu.entries, e.UserTestID)
+	}
+}
+
+// process обновляет сообщения таймеров, время которых наступило, и завершает тесты с истекшим временем
+func (tu *Updater) process(ctx context.Context, now time.Time) {
+	due := tu.popDue(now)
+	if len(due) == 0 {
+		return
+	}
+
+	userTestIDs := make([]int, 0, len(due))
+	for _, e := range due {
+		userTestIDs = append(userTestIDs, e.UserTestID)
+	}
+
+	// Получаем текущее состояние всех тестов одним запросом
+	progress, err := tu.testService.GetUserTestsProgress(ctx, userTestIDs)
+	if err != nil {
+		log.Printf("Failed to get user tests progress: %v", err)
+		for _, e := range due {
+			tu.reschedule(e, now.Add(retryInterval))
+		}
+		return
+	}
+
+	for _, e := range due {
+		// Планировщик останавливается, оставшиеся таймеры будут восстановлены при следующем запуске
+		if ctx.Err() != nil {
+			return
+		}
+
+		p, ok := progress[e.UserTestID]
+
+		// Если тест уже завершен, прекращаем обновление таймера
+		if !ok || p.Status != model.UserTestStatusInProgress {
+			tu.forget(e)
+			continue
+		}
+
+		// Время вышло, завершаем тест, если кандидат еще не ответил на все вопросы
+		if !now.Before(e.Deadline) {
+			tu.finish(ctx, e, "⏰ Время вышло!")
+			tu.forget(e)
+			continue
+		}
 
-			// Получаем текущее состояние теста (индекс текущего вопроса)
-			currentQuestionIndex, _, status, err := tu.testService.GetUserTestState(ctx, userTestID)
+		// Время на ответ истекло, вопрос засчитывается без ответа и кандидат переходит к следующему
+		if tu.presenter != nil && p.QuestionDeadline != nil && !now.Before(*p.QuestionDeadline) {
+			last, err := tu.presenter.ExpireQuestion(ctx, e.TelegramID, e.UserTestID, p)
 			if err != nil {
-				log.Printf("Failed to get user test state for user %d: %v", userID, err)
+				log.Printf("Failed to expire question for user %d: %v", e.TelegramID, err)
+				tu.reschedule(e, now.Add(retryInterval))
 				continue
 			}
-
-			// Если тест уже завершен, прекращаем обновление таймера
-			if status == "finished" {
-				log.Printf("Test already finished for user %d", userID)
-				return
+			if last {
+				tu.finish(ctx, e, "⏰ Время на последний вопрос истекло. Тест завершен, ваши ответы сохранены.")
+				tu.forget(e)
+				continue
 			}
+			// Следующий вопрос уже показан, сразу обрабатываем его ограничение времени
+			tu.reschedule(e, now)
+			continue
+		}
 
-			// Вычисляем минуты и секунды
-			minutes := int(timeLeft.Minutes())
-			seconds := int(timeLeft.Seconds()) % 60
-
-			// Формируем текст сообщения с таймером и номером вопроса
-			timerText := fmt.Sprintf(
-				"⏰ Тест начался! Оставшееся время: %02d:%02d, Вопрос %d/%d",
-				minutes, seconds, currentQuestionIndex+1, totalQuestions,
-			)
-
-			// Обновляем сообщение с таймером
-			_, err = tu.bot.Edit(&telebot.Message{
-				ID:   messageID,
-				Chat: &telebot.Chat{ID: userID},
-			}, timerText, &telebot.SendOptions{
-				ParseMode: telebot.ModeMarkdown,
-			})
-			if err != nil {
-				log.Printf("Failed to update timer message for user %d: %w", userID, err)
-			}
+		nextUpdate := tu.render(e, p, now)
+		if tu.presenter != nil && p.QuestionDeadline != nil {
+			nextUpdate = earliest(nextUpdate, tu.renderCountdown(ctx, e, p, now))
+		}
+		tu.reschedule(e, nextUpdate)
+	}
+}
+
+// render обновляет сообщение с таймером и возвращает время следующего обновления.
+// Одинаковый текст повторно не отправляется, а при исчерпании лимита обновление откладывается
+// и при следующей попытке будет отправлено уже актуальное состояние.
+func (tu *Updater) render(e *entry, p model.UserTestProgress, now time.Time) time.Time {
+	timeLeft := e.Deadline.Sub(now)
+	nextUpdate := earliest(now.Add(refreshInterval(timeLeft)), e.Deadline)
+
+	if e.MessageID == 0 {
+		return nextUpdate
+	}
+
+	text := FormatTimer(timeLeft, p.CurrentQuestionIndex, e.TotalQuestions)
+	if text == e.lastText {
+		return nextUpdate
+	}
+
+	if !tu.limiter.Allow(now) {
+		return earliest(later(now.Add(retryInterval), tu.limiter.PausedUntil()), e.Deadline)
+	}
+
+	_, err := tu.bot.Edit(&telebot.Message{
+		ID:   e.MessageID,
+		Chat: &telebot.Chat{ID: e.TelegramID},
+	}, text, &telebot.SendOptions{
+		ParseMode: telebot.ModeMarkdown,
+	})
+	if err != nil {
+		if retryAt, limited := tu.handleFlood(err, now); limited {
+			return earliest(retryAt, e.Deadline)
+		}
+		if !errors.Is(err, telebot.ErrSameMessageContent) && !errors.Is(err, telebot.ErrMessageNotModified) {
+			log.Printf("Failed to update timer message for user %d: %v", e.TelegramID, err)
+			return nextUpdate
+		}
+	}
+
+	e.lastText = text
+	return nextUpdate
+}
+
+// renderCountdown обновляет обратный отсчет в сообщении с вопросом и возвращает время следующего обновления
+func (tu *Updater) renderCountdown(ctx context.Context, e *entry, p model.UserTestProgress, now time.Time) time.Time {
+	deadline := *p.QuestionDeadline
+	nextUpdate := earliest(now.Add(countdownInterval), deadline)
+
+	if p.QuestionMessageID == 0 {
+		return nextUpdate
+	}
+
+	if !tu.limiter.Allow(now) {
+		return earliest(later(now.Add(retryInterval), tu.limiter.PausedUntil()), deadline)
+	}
+
+	err := tu.presenter.ShowCountdown(ctx, e.TelegramID, e.UserTestID, p, deadline.Sub(now))
+	if err != nil {
+		if retryAt, limited := tu.handleFlood(err, now); limited {
+			return earliest(retryAt, deadline)
 		}
+		if !errors.Is(err, telebot.ErrSameMessageContent) && !errors.Is(err, telebot.ErrMessageNotModified) {
+			log.Printf("Failed to update question countdown for user %d: %v", e.TelegramID, err)
+		}
+	}
+
+	return nextUpdate
+}
+
+// handleFlood приостанавливает все обновления, если Telegram ответил 429 Too Many Requests
+func (tu *Updater) handleFlood(err error, now time.Time) (time.Time, bool) {
+	var floodErr telebot.FloodError
+	if !errors.As(err, &floodErr) {
+		return time.Time{}, false
+	}
+
+	retryAt := now.Add(time.Duration(max(floodErr.RetryAfter, 1)) * time.Second)
+	tu.limiter.Pause(retryAt)
+	log.Printf("Telegram flood limit reached, timer updates paused for %d s", floodErr.RetryAfter)
+	return retryAt, true
+}
+
+// finish завершает тест по истечении времени и уведомляет кандидата и назначившего HR.
+// timerText заменяет текст сообщения с таймером.
+func (tu *Updater) finish(ctx context.Context, e *entry, timerText string) {
+	status, err := tu.testService.FinishUserTest(ctx, e.UserTestID)
+	if err != nil {
+		log.Printf("Failed to finish test for user %d: %v", e.TelegramID, err)
+		return
+	}
+	if status == "" {
+		// Тест уже завершен кандидатом
+		return
+	}
+
+	// Отправляем сообщение о завершении времени. Это обновление обязательное, поэтому ждем свободный лимит.
+	if e.MessageID != 0 && tu.limiter.Wait(ctx) == nil {
+		_, err = tu.bot.Edit(&telebot.Message{
+			ID:   e.MessageID,
+			Chat: &telebot.Chat{ID: e.TelegramID},
+		}, timerText, &telebot.SendOptions{
+			ParseMode: telebot.ModeMarkdown,
+		})
+		if err != nil {
+			log.Printf("Failed to update timer message for user %d: %v", e.TelegramID, err)
+		}
+	}
+
+	// Получаем пользователя, который назначил тест
+	userTest, err := tu.userService.GetUserTestByID(ctx, e.UserTestID)
+	if err != nil {
+		log.Printf("Failed to get user test %d in timer: %v", e.UserTestID, err)
+		return
+	}
+
+	assignedBy, err := tu.userService.GetUserByID(ctx, userTest.AssignedBy)
+	if err != nil || assignedBy == nil || assignedBy.TelegramID == nil {
+		log.Printf("Failed to get assigning HR for user test %d in timer: %v", e.UserTestID, err)
+		return
+	}
+
+	user, err := tu.userService.GetUserByTelegramID(ctx, e.TelegramID)
+	if err != nil || user == nil {
+		log.Printf("Failed to get user %d in timer: %v", e.TelegramID, err)
+		return
+	}
+
+	test, err := tu.testService.GetTestByID(ctx, userTest.TestID)
+	if err != nil || test == nil {
+		log.Printf("Failed to get test %d in timer: %v", userTest.TestID, err)
+		return
+	}
+
+	// Отправляем сообщение о завершении теста пользователю assigned_by
+	finishMessage := fmt.Sprintf("⚡️ Кандидат *%s* завершил выполнение теста *%s*.", user.TelegramUsername, test.TestName)
+	if status == model.UserTestStatusPendingReview {
+		finishMessage += "\nЧасть ответов ожидает ручной проверки, итоговый балл будет доступен после нее."
+	}
+	_, err = tu.bot.Send(&telebot.User{ID: *assignedBy.TelegramID}, finishMessage, &telebot.SendOptions{
+		ParseMode: telebot.ModeMarkdown,
+	})
+	if err != nil {
+		log.Printf("Failed to notify HR about finished test %d: %v", e.UserTestID, err)
+	}
+}
+
+// earliest возвращает более раннее из двух значений времени
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// later возвращает более позднее из двух значений времени
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
 	}
+	return b
 }
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS test_links_created_by_idx;
+
+ALTER TABLE IF EXISTS test_links
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS max_uses,
+    DROP COLUMN IF EXISTS use_count,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS updated_at;
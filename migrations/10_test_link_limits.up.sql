@@ -0,0 +1,14 @@
+-- Ограничения ссылок на тесты: ссылка привязана к выпустившему ее HR, может истекать,
+-- иметь лимит использований и быть отозвана
+ALTER TABLE test_links
+    ADD COLUMN IF NOT EXISTS created_by INT REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS max_uses INT CHECK (max_uses > 0),
+    ADD COLUMN IF NOT EXISTS use_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+
+-- Ссылки, выпущенные до привязки к HR, отзываются: автор в них брался из самой ссылки
+UPDATE test_links SET revoked_at = CURRENT_TIMESTAMP WHERE created_by IS NULL AND revoked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS test_links_created_by_idx ON test_links (created_by);
@@ -0,0 +1 @@
+DELETE FROM messages WHERE message_key IN ('generate_qr', 'qr_link_created');
@@ -0,0 +1,9 @@
+-- Кнопка генерации QR-кода ссылки на тест в Telegram
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('generate_qr', '🔳 QR-код на тест'),
+    ('qr_link_created', '🔳 QR-код для теста <b>%s</b>
+Ссылка: %s
+Срок действия: %s
+Лимит использований: %s')
+ON CONFLICT (message_key) DO NOTHING;
@@ -0,0 +1,8 @@
+DELETE FROM messages WHERE message_key IN ('assignment_expired_candidate', 'assignment_expired_hr');
+
+DROP INDEX IF EXISTS user_tests_deadline_idx;
+
+UPDATE user_tests SET status = 'assigned' WHERE status = 'expired';
+
+ALTER TABLE IF EXISTS user_tests
+    DROP COLUMN IF EXISTS deadline;
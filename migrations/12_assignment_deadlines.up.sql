@@ -0,0 +1,12 @@
+-- Срок, до которого кандидат должен начать назначенный тест. Просроченные назначения переводятся в статус expired
+ALTER TABLE user_tests
+    ADD COLUMN IF NOT EXISTS deadline TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS user_tests_deadline_idx ON user_tests (deadline)
+    WHERE status IN ('pending', 'assigned') AND deadline IS NOT NULL;
+
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('assignment_expired_candidate', '⌛ Срок прохождения теста <b>%s</b> истек %s. Если вы все еще хотите пройти тест, свяжитесь с HR.'),
+    ('assignment_expired_hr', '⌛ Кандидат @%s не начал тест <b>%s</b> до %s. Назначение просрочено.')
+ON CONFLICT (message_key) DO NOTHING;
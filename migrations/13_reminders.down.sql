@@ -0,0 +1,3 @@
+DELETE FROM messages WHERE message_key IN ('reminder_before_deadline', 'reminder_after_assignment');
+
+DROP TABLE IF EXISTS sent_reminders;
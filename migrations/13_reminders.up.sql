@@ -0,0 +1,16 @@
+-- Отправленные напоминания о не начатых тестах. Запись создается до отправки, поэтому после перезапуска
+-- напоминание не дублируется
+CREATE TABLE IF NOT EXISTS sent_reminders
+(
+    id SERIAL PRIMARY KEY,
+    user_test_id INT NOT NULL REFERENCES user_tests(id) ON DELETE CASCADE,
+    reminder_key VARCHAR(64) NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_test_id, reminder_key)
+);
+
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('reminder_before_deadline', '⏰ Напоминаем: тест <b>%s</b> нужно начать до %s.'),
+    ('reminder_after_assignment', '👋 Вам назначен тест <b>%s</b>, но вы его еще не начали.')
+ON CONFLICT (message_key) DO NOTHING;
@@ -0,0 +1,3 @@
+DELETE FROM messages WHERE message_key IN ('assignment_cancelled', 'assignment_invitation', 'assignment_transferred');
+
+UPDATE user_tests SET status = 'assigned' WHERE status = 'cancelled';
@@ -0,0 +1,10 @@
+-- Управление назначениями из Telegram: отмена, замена теста, передача другому HR и повторное приглашение
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('assignment_cancelled', '❌ Назначение теста <b>%s</b> отменено вашим HR менеджером.'),
+    ('assignment_invitation', '👋 Вам назначен тест <b>%s</b>.
+На тест дается <b>%d</b> минут. Он состоит из <b>%d</b> вопросов.
+
+Нажмите кнопку <b>Начать тест</b>, чтобы приступить.'),
+    ('assignment_transferred', '📨 @%s передал(а) вам назначение теста <b>%s</b> кандидату @%s.')
+ON CONFLICT (message_key) DO NOTHING;
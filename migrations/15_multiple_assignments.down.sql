@@ -0,0 +1 @@
+DELETE FROM messages WHERE message_key IN ('welcome_message_user_multiple', 'choose_test');
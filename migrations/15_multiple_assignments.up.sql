@@ -0,0 +1,14 @@
+-- Выбор теста кандидатом, которому назначено несколько тестов
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('welcome_message_user_multiple', '👋 <b>%s</b>, добро пожаловать в бота для тестирования кандидатов в компанию <b>Первый бит</b>!
+
+Вам назначено несколько тестов:
+%s
+Нажмите кнопку с названием теста, чтобы приступить.
+
+🍀 <b>Удачи!</b>'),
+    ('choose_test', 'Вам назначено несколько тестов:
+%s
+Выберите, какой тест начать.')
+ON CONFLICT (message_key) DO NOTHING;
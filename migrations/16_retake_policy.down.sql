@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_user_tests_attempt_of;
+
+DELETE FROM user_tests WHERE attempt_of IS NOT NULL;
+
+ALTER TABLE user_tests
+    DROP COLUMN IF EXISTS attempt_number,
+    DROP COLUMN IF EXISTS attempt_of;
+
+ALTER TABLE tests
+    DROP COLUMN IF EXISTS retake_cooldown,
+    DROP COLUMN IF EXISTS max_attempts;
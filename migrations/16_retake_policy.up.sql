@@ -0,0 +1,11 @@
+-- Политика повторного прохождения: лимит попыток и пауза между ними задаются для каждого теста
+ALTER TABLE tests
+    ADD COLUMN IF NOT EXISTS max_attempts INT NOT NULL DEFAULT 1 CHECK (max_attempts > 0),
+    ADD COLUMN IF NOT EXISTS retake_cooldown INTERVAL NOT NULL DEFAULT INTERVAL '0';
+
+-- Каждая повторная попытка хранится отдельной строкой, связанной с исходным назначением (первой попыткой)
+ALTER TABLE user_tests
+    ADD COLUMN IF NOT EXISTS attempt_of INT REFERENCES user_tests(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS attempt_number INT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS idx_user_tests_attempt_of ON user_tests(attempt_of) WHERE attempt_of IS NOT NULL;
@@ -0,0 +1,13 @@
+UPDATE messages
+SET message_text = replace(message_text, 'Он состоит из <b>%d</b> вопросов.%s', 'Он состоит из <b>%d</b> вопросов.')
+WHERE message_key = 'welcome_message_user';
+
+ALTER TABLE test_links
+    DROP CONSTRAINT IF EXISTS test_links_availability_window_check,
+    DROP COLUMN IF EXISTS available_until,
+    DROP COLUMN IF EXISTS available_from;
+
+ALTER TABLE user_tests
+    DROP CONSTRAINT IF EXISTS user_tests_availability_window_check,
+    DROP COLUMN IF EXISTS available_until,
+    DROP COLUMN IF EXISTS available_from;
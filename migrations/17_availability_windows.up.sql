@@ -0,0 +1,29 @@
+-- Окна доступности: тест можно начать только в интервале [available_from, available_until).
+-- Задаются для назначения и для ссылки на тест; назначение по ссылке получает окно ссылки
+ALTER TABLE user_tests
+    ADD COLUMN IF NOT EXISTS available_from TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS available_until TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE test_links
+    ADD COLUMN IF NOT EXISTS available_from TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS available_until TIMESTAMP WITH TIME ZONE;
+
+-- ADD CONSTRAINT не поддерживает IF NOT EXISTS, поэтому наличие ограничений проверяется отдельно
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'user_tests_availability_window_check') THEN
+        ALTER TABLE user_tests
+            ADD CONSTRAINT user_tests_availability_window_check CHECK (available_from < available_until);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'test_links_availability_window_check') THEN
+        ALTER TABLE test_links
+            ADD CONSTRAINT test_links_availability_window_check CHECK (available_from < available_until);
+    END IF;
+END $$;
+
+-- Окно доступности выводится в приветствии отдельной строкой (пустая строка, если окна нет).
+-- Условие не дает добавить второй %s при повторном применении миграции
+UPDATE messages
+SET message_text = replace(message_text, 'Он состоит из <b>%d</b> вопросов.', 'Он состоит из <b>%d</b> вопросов.%s')
+WHERE message_key = 'welcome_message_user'
+  AND message_text NOT LIKE '%вопросов.\%s%';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_answers_user_test_question;
+
+ALTER TABLE tests
+    DROP COLUMN IF EXISTS free_navigation;
@@ -0,0 +1,13 @@
+-- Свободная навигация: кандидат может пропускать вопросы, возвращаться к ним и менять ответы до отправки теста
+ALTER TABLE tests
+    ADD COLUMN IF NOT EXISTS free_navigation BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- На каждый вопрос попытки хранится один ответ: повторный ответ заменяет предыдущий (ON CONFLICT).
+-- Дубли, оставшиеся от повторных нажатий, удаляются, сохраняется последний ответ
+DELETE FROM answers a
+    USING answers newer
+WHERE newer.user_test_id = a.user_test_id
+  AND newer.question_id = a.question_id
+  AND newer.id > a.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_answers_user_test_question ON answers(user_test_id, question_id);
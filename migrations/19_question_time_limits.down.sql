@@ -0,0 +1,11 @@
+ALTER TABLE answers
+    DROP COLUMN IF EXISTS timed_out,
+    DROP COLUMN IF EXISTS elapsed_seconds;
+
+ALTER TABLE user_tests
+    DROP COLUMN IF EXISTS question_selection,
+    DROP COLUMN IF EXISTS question_message_id,
+    DROP COLUMN IF EXISTS question_started_at;
+
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS time_limit_seconds;
@@ -0,0 +1,15 @@
+-- Ограничение времени на ответ для отдельных вопросов (в дополнение к общему tests.duration)
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS time_limit_seconds INT CHECK (time_limit_seconds > 0);
+
+-- Состояние текущего вопроса: когда он показан, сообщение с обратным отсчетом и отмеченные варианты
+-- вопроса с несколькими ответами, чтобы обновление отсчета не сбрасывало выбор
+ALTER TABLE user_tests
+    ADD COLUMN IF NOT EXISTS question_started_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS question_message_id INT,
+    ADD COLUMN IF NOT EXISTS question_selection BIGINT NOT NULL DEFAULT 0;
+
+-- Время ответа на вопрос для отчетов и признак того, что время на ответ истекло
+ALTER TABLE answers
+    ADD COLUMN IF NOT EXISTS elapsed_seconds INT,
+    ADD COLUMN IF NOT EXISTS timed_out BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+ALTER TABLE user_tests
+    DROP COLUMN IF EXISTS option_orders;
+
+ALTER TABLE tests
+    DROP COLUMN IF EXISTS shuffle_options;
@@ -0,0 +1,7 @@
+-- Перемешивание вариантов ответа: порядок показа генерируется для каждого прохождения теста
+ALTER TABLE tests
+    ADD COLUMN IF NOT EXISTS shuffle_options BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Порядок вариантов по ID вопроса: {"<question_id>": [индексы test_options в порядке показа]}
+ALTER TABLE user_tests
+    ADD COLUMN IF NOT EXISTS option_orders JSONB;
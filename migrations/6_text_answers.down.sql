@@ -0,0 +1,4 @@
+ALTER TABLE questions
+    DROP COLUMN IF EXISTS answer_matcher,
+    DROP COLUMN IF EXISTS accepted_answers,
+    DROP COLUMN IF EXISTS numeric_tolerance;
@@ -0,0 +1,5 @@
+-- Настройки автоматической проверки вопросов со свободным ответом (answer_type = 'text')
+ALTER TABLE questions
+    ADD COLUMN IF NOT EXISTS answer_matcher VARCHAR(50) NOT NULL DEFAULT 'exact', -- exact, case_insensitive, regex, numeric, synonyms
+    ADD COLUMN IF NOT EXISTS accepted_answers JSONB,                            -- Синонимы правильного ответа для matcher = 'synonyms'
+    ADD COLUMN IF NOT EXISTS numeric_tolerance DOUBLE PRECISION;                 -- Допустимая погрешность для matcher = 'numeric'
@@ -0,0 +1,9 @@
+DELETE FROM messages WHERE message_key = 'review_answers';
+
+DROP INDEX IF EXISTS answers_pending_review_idx;
+
+ALTER TABLE answers
+    DROP COLUMN IF EXISTS review_status,
+    DROP COLUMN IF EXISTS review_comment,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS reviewed_at;
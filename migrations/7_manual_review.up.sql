@@ -0,0 +1,13 @@
+-- Ручная проверка свободных ответов, которые нельзя проверить автоматически
+ALTER TABLE answers
+    ADD COLUMN IF NOT EXISTS review_status VARCHAR(50) NOT NULL DEFAULT 'auto', -- auto, pending, reviewed
+    ADD COLUMN IF NOT EXISTS review_comment TEXT,
+    ADD COLUMN IF NOT EXISTS reviewed_by INT REFERENCES users(id),
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS answers_pending_review_idx ON answers (created_at) WHERE review_status = 'pending';
+
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('review_answers', '📝 Проверить ответы')
+ON CONFLICT (message_key) DO NOTHING;
@@ -0,0 +1,7 @@
+DELETE FROM messages WHERE message_key = 'api_key_issued';
+
+DELETE FROM role_permissions
+WHERE permission_id IN (SELECT id FROM permissions WHERE permission_name = 'view_reports');
+DELETE FROM permissions WHERE permission_name = 'view_reports';
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,34 @@
+-- API ключи для HTTP API. Хранится только SHA-256 хеш ключа, сам ключ показывается пользователю один раз
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    key_hash VARCHAR(64) UNIQUE NOT NULL,
+    name VARCHAR(255),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
+
+-- Право на просмотр отчетов через HTTP API
+INSERT INTO permissions (permission_name)
+VALUES
+    ('view_reports')
+ON CONFLICT (permission_name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.role_name IN ('manager', 'admin') AND p.permission_name = 'view_reports'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('api_key_issued', '🔑 Ваш API ключ (показывается один раз, сохраните его):
+`%s`
+
+Передавайте его в заголовке `Authorization: Bearer <ключ>`.')
+ON CONFLICT (message_key) DO NOTHING;
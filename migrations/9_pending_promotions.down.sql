@@ -0,0 +1,4 @@
+DELETE FROM messages WHERE message_key IN ('assign_role_enter_username', 'role_promoted', 'pending_promotion_applied');
+
+DROP INDEX IF EXISTS pending_promotions_username_idx;
+DROP TABLE IF EXISTS pending_promotions;
@@ -0,0 +1,22 @@
+-- Отложенные назначения ролей для пользователей, которые еще не написали /start (по аналогии с отложенными тестами)
+CREATE TABLE IF NOT EXISTS pending_promotions
+(
+    id SERIAL PRIMARY KEY,
+    telegram_username VARCHAR(255) NOT NULL,
+    role_id INT NOT NULL REFERENCES roles(id),
+    promoted_by INT NOT NULL REFERENCES users(id),
+    status VARCHAR(50) NOT NULL DEFAULT 'pending', -- pending, applied
+    applied_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Для пользователя действует только последнее отложенное назначение
+CREATE UNIQUE INDEX IF NOT EXISTS pending_promotions_username_idx ON pending_promotions (telegram_username) WHERE status = 'pending';
+
+INSERT INTO messages (message_key, message_text)
+VALUES
+    ('assign_role_enter_username', 'Введите имя пользователя, которому нужно назначить роль <b>%s</b> (например, @username).'),
+    ('role_promoted', '🎉 Вам назначена роль <b>%s</b>. Напишите /start, чтобы обновить меню.'),
+    ('pending_promotion_applied', '✅ Пользователь @%s написал /start, ему назначена роль <b>%s</b>.')
+ON CONFLICT (message_key) DO NOTHING;
@@ -0,0 +1,9 @@
+#!/bin/sh
+# Применяет *.up.sql миграции в порядке номеров (1, 2, ..., 10, ...).
+# Стандартный порядок docker-entrypoint-initdb.d лексикографический и выполнил бы 10_* раньше 1_*.
+set -e
+
+for migration in $(ls /migrations/*.up.sql | sort -V); do
+    echo "applying $migration"
+    psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" -f "$migration"
+done